@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+	"sheeper.com/fancaps-scraper-go/pkg/ui"
+)
+
+/* Debug: Print found titles `titles`. */
+func printFoundTitles(titles []*types.Title) {
+	maxTitleWidth := len(ui.GetLongestTitle(titles))
+
+	fmt.Println("\n\nFOUND TITLES:")
+	for _, t := range titles {
+		fmt.Printf("%-*s -> %s\n", maxTitleWidth, t.Name, t.Url)
+	}
+	fmt.Printf("\n\n")
+}
+
+/* Debug: Print titles `titles` resolved from title arguments. */
+func printResolvedTitles(titles []*types.Title) {
+	fmt.Println("\n\nRESOLVED TITLES:")
+	for _, t := range titles {
+		fmt.Printf("%s [%s] -> %s\n", t.Name, t.Category, t.Url)
+	}
+	fmt.Printf("\n\n")
+}
+
+/* Debug: Print found titles `titles` and their episodes. */
+func printFoundEpisodes(titles []*types.Title) {
+	fmt.Println("\n\nFOUND TITLES AND EPISODES:")
+	for _, title := range titles {
+		fmt.Printf("%s [%s] -> %s\n", title.Name, title.Category, title.Url)
+		for _, episode := range title.Episodes {
+			fmt.Printf("\t%s -> %s\n", episode.Name, episode.Url)
+		}
+	}
+	fmt.Printf("\n\n")
+}
+
+/* Debug: Print amount of found images per title/episode of titles `titles`. */
+func printFoundImages(titles []*types.Title) {
+	fmt.Println("\n\nFOUND IMAGES:")
+	for _, title := range titles {
+		fmt.Printf("%s [%s] -> %d images\n", title.Name, title.Category, title.Images.Total())
+
+		if title.Category == types.CategoryMovie {
+			continue // Don't show movie episodes. They don't have any.
+		}
+
+		for _, episode := range title.Episodes {
+			fmt.Printf("\t%s -> %d images\n", episode.Name, episode.Images.Total())
+		}
+	}
+	fmt.Printf("\n\n")
+}
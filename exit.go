@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"sheeper.com/fancaps-scraper-go/pkg/logf"
+	"sheeper.com/fancaps-scraper-go/pkg/scraper"
+	"sheeper.com/fancaps-scraper-go/pkg/ui"
+)
+
+/* Exit codes of the CLI. (Documented in the usage of `cli.ParseCLI()`) */
+const (
+	exitOK            = 0   // Success.
+	exitError         = 1   // Any other error. (e.g., invalid flags, unreadable input, unwritable output)
+	exitRateLimited   = 2   // Downloads were aborted, since the server kept rate-limiting them.
+	exitEmptyQuery    = 3   // A search query or title name is blank.
+	exitNotFound      = 4   // A search or title name matched no titles.
+	exitLayoutChanged = 5   // A page did not have the expected layout.
+	exitBadStatus     = 6   // A page was answered with an unexpected HTTP status code.
+	exitImagesFailed  = 7   // Some images still failed to download after all retries.
+	exitInterrupted   = 130 // The run was interrupted. (SIGINT/SIGTERM)
+)
+
+/* Called by `exit()` right before the program exits. (e.g., to write the report of the run. See `writeReportOnExit()`) */
+var beforeExit = func() {}
+
+/* Calls `beforeExit()`, then exits with code `code`. */
+func exit(code int) {
+	beforeExit()
+	os.Exit(code)
+}
+
+/* Returns the exit code for the error `err`. */
+func exitCode(err error) int {
+	var statusErr *scraper.ErrBadStatus
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, scraper.ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, scraper.ErrEmptyQuery):
+		return exitEmptyQuery
+	case errors.Is(err, scraper.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, scraper.ErrLayoutChanged):
+		return exitLayoutChanged
+	case errors.As(err, &statusErr):
+		return exitBadStatus
+	default:
+		return exitError
+	}
+}
+
+/*
+Prints the error `err` and exits with its exit code. (See `exitCode()`)
+If the context `ctx` was canceled, exits as interrupted instead. (See `exitIfInterrupted()`)
+Log statistics are printed before exiting.
+*/
+func exitOnError(ctx context.Context, err error) {
+	exitIfInterrupted(ctx)
+
+	code := exitCode(err)
+	switch code {
+	case exitRateLimited:
+		fmt.Fprintln(os.Stderr, "\n"+
+			ui.ErrStyle.Render("You are being rate-limited. Try again later.")+"\n"+
+			ui.ErrStyle.Render("Hint: Try setting `--parallel-downloads` to a lower value."))
+	case exitLayoutChanged:
+		fmt.Fprintln(os.Stderr, ui.ErrStyle.Render(err.Error())+"\n"+
+			ui.ErrStyle.Render("Hint: fancaps.net may have changed its layout. Please report this issue."))
+	default:
+		fmt.Fprintln(os.Stderr, ui.ErrStyle.Render(err.Error()))
+	}
+
+	logf.PrintStats()
+	exit(code)
+}
+
+/*
+Exits with code 130, if the context `ctx` was canceled (i.e., the run was interrupted).
+Log statistics are printed before exiting.
+*/
+func exitIfInterrupted(ctx context.Context) {
+	if ctx.Err() == nil {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "\n"+ui.ErrStyle.Render("Interrupted. Operation aborted."))
+	logf.PrintStats()
+	exit(exitInterrupted)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/cli"
+	"sheeper.com/fancaps-scraper-go/pkg/job"
+	"sheeper.com/fancaps-scraper-go/pkg/report"
+	"sheeper.com/fancaps-scraper-go/pkg/scraper"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/* Exits early, as after a layout change, with a report recorded to the file named by the environment variable below. */
+const earlyExitReportEnv = "FSG_TEST_EARLY_EXIT_REPORT"
+
+func TestEarlyExitWritesReport(t *testing.T) {
+	if filename := os.Getenv(earlyExitReportEnv); filename != "" {
+		client, err := scraper.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		flags := cli.CLIFlags{OutputDir: filepath.Dir(filename), Report: filename}
+		titles := []*types.Title{{Name: "Title", Url: "https://fancaps.net/anime/showimages.php?1-Title", Images: &types.Images{}}}
+		var manifest *job.Manifest
+
+		writeReportOnExit(flags, client, report.NewRecorder(), &titles, &manifest)
+		exitOnError(context.Background(), scraper.ErrLayoutChanged)
+		return
+	}
+
+	filename := filepath.Join(t.TempDir(), "report.json")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestEarlyExitWritesReport$")
+	cmd.Env = append(os.Environ(), earlyExitReportEnv+"="+filename)
+
+	var exitErr *exec.ExitError
+	if err := cmd.Run(); !errors.As(err, &exitErr) || exitErr.ExitCode() != exitLayoutChanged {
+		t.Fatalf("early exit: got %v, expected exit code %d", err, exitLayoutChanged)
+	}
+
+	rep, err := report.Load(filename)
+	if err != nil {
+		t.Fatalf("no report after an early exit: %v", err)
+	}
+	if len(rep.Titles) != 1 || rep.Titles[0].Name != "Title" {
+		t.Errorf("report titles = %+v, expected the title of the run", rep.Titles)
+	}
+}
@@ -8,6 +8,7 @@ require (
 	github.com/charmbracelet/lipgloss v1.1.0
 	github.com/gocolly/colly v1.2.0
 	github.com/spf13/pflag v1.0.6
+	golang.org/x/net v0.40.0
 )
 
 require (
@@ -37,7 +38,6 @@ require (
 	github.com/saintfish/chardet v0.0.0-20230101081208-5e3ef4b5456d // indirect
 	github.com/temoto/robotstxt v1.1.2 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
-	golang.org/x/net v0.40.0 // indirect
 	golang.org/x/sync v0.14.0
 	golang.org/x/sys v0.34.0 // indirect
 	golang.org/x/term v0.33.0
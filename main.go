@@ -1,10 +1,22 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"sheeper.com/fancaps-scraper-go/pkg/cli"
 	"sheeper.com/fancaps-scraper-go/pkg/format"
+	"sheeper.com/fancaps-scraper-go/pkg/httpclient"
+	"sheeper.com/fancaps-scraper-go/pkg/job"
 	"sheeper.com/fancaps-scraper-go/pkg/logf"
+	"sheeper.com/fancaps-scraper-go/pkg/progress"
+	"sheeper.com/fancaps-scraper-go/pkg/report"
 	"sheeper.com/fancaps-scraper-go/pkg/scraper"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+	"sheeper.com/fancaps-scraper-go/pkg/ui"
 	"sheeper.com/fancaps-scraper-go/pkg/ui/menu"
 	"sheeper.com/fancaps-scraper-go/pkg/ui/prompt"
 )
@@ -16,30 +28,197 @@ func main() {
 	/* Get parsed flags. */
 	flags := cli.Flags()
 
-	/* Get URLs to search through. */
-	searchURLs := scraper.GetSearchURLs(flags.Queries, flags.Categories)
+	/* Keep JSON progress apart from any other output. */
+	progressOut := reserveStdout(flags)
+
+	/* Record the run for its report, if requested. */
+	var recorder *report.Recorder
+	if flags.Report != "" {
+		recorder = report.NewRecorder()
+	}
+
+	/* Log to the output directory, unless disabled. */
+	logf.Configure(flags.OutputDir, !flags.NoLog)
+
+	client, err := newClient(flags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ErrStyle.Render(err.Error()))
+		os.Exit(exitError)
+	}
+
+	/*
+		Cancel the run on SIGINT/SIGTERM, letting in-flight work wind down cleanly.
+		A second signal restores the default behavior. (i.e., forcefully quits)
+	*/
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	var (
+		selectedTitles []*types.Title // Titles to scrape from.
+		scraped        bool           // If true, the selected titles already hold their episodes and images.
+		streaming      bool           // If true, images are downloaded while their pages are still being scraped.
+		manifest       *job.Manifest  // Job manifest recording the progress of downloads.
+	)
+	if recorder != nil {
+		writeReportOnExit(flags, client, recorder, &selectedTitles, &manifest)
+	}
+
+	/* New jobs replace the job manifest of the output directory. Ask first, before any scraping. */
+	newJob := !flags.DryRun && flags.Resume == "" && flags.RetryFailed == ""
+	if newJob {
+		confirmNewJob(flags.OutputDir)
+	}
+
+	switch {
+	case flags.Resume != "": /* Resumed job: Skip scraping, keeping only images left to download. */
+		manifest, selectedTitles = loadManifest(flags.Resume)
+		scraped = true
+	case flags.RetryFailed != "": /* Retried job: Skip scraping, keeping only images which failed. */
+		manifest, selectedTitles = loadFailedImages(flags.RetryFailed)
+		scraped = true
+	case flags.Input != "": /* Titles read from a file: Skip searching and the title menu. */
+		selectedTitles, scraped, err = client.LoadInput(ctx, flags.Input, flags.Categories)
+		if err != nil {
+			exitOnError(ctx, fmt.Errorf("input error: %w", err))
+		}
+		if flags.Debug && !scraped {
+			printResolvedTitles(selectedTitles)
+		}
+	case len(flags.Titles) > 0: /* Titles given directly: Skip searching and the title menu. */
+		selectedTitles, err = client.ResolveTitles(ctx, flags.Titles, flags.Categories)
+		if err != nil {
+			exitOnError(ctx, err)
+		}
+		if flags.Debug {
+			printResolvedTitles(selectedTitles)
+		}
+	default:
+		/* Get titles matching user queries. */
+		titles := searchTitles(ctx, client, flags.Queries, flags.Categories)
+		exitIfInterrupted(ctx)
+		if flags.Debug {
+			printFoundTitles(titles)
+		}
+
+		/* Allow the user to choose which titles to scrape from. Without a terminal for the menu, prompt for title numbers. */
+		if ui.StdinIsTerminal() && ui.StdoutIsTerminal() {
+			selectedTitles = menu.LaunchTitleMenu(titles, flags.Categories, flags.MenuLines, flags.Debug)
+		} else {
+			selectedTitles = prompt.SelectTitles(titles, flags.Debug)
+		}
+	}
+	exitIfInterrupted(ctx)
+
+	/* Resumed and retried jobs keep naming their files the way they were started with. */
+	if manifest != nil && flags.FrameNames && !manifest.FrameNames {
+		fmt.Fprintf(os.Stderr, ui.HighlightStyle.Render("%s names images by URL; ignoring --frame-names.")+"\n", manifest.Path())
+	}
 
-	/* Get titles matching user query. */
-	titles := scraper.GetTitles(searchURLs)
+	if !scraped {
+		/* Get episodes from selected titles. Titles whose episodes could not be scraped are left out, and reported at the end. */
+		if err := client.ScrapeEpisodes(ctx, selectedTitles); err != nil {
+			exitIfInterrupted(ctx)
+			selectedTitles = withEpisodes(selectedTitles)
+			if len(selectedTitles) == 0 {
+				exitWithSummary(client, selectedTitles)
+			}
+		}
+		if flags.Debug {
+			printFoundEpisodes(selectedTitles)
+		}
 
-	/* Allow the user to choose which titles to scrape from. */
-	selectedTitles := menu.LaunchTitleMenu(titles, flags.Categories, flags.MenuLines, flags.Debug)
+		/* Select episodes to scrape from each title. */
+		prompt.SelectEpisodes(selectedTitles, flags.Episodes, flags.Debug)
 
-	/* Get episodes from selected titles. */
-	scraper.GetEpisodes(selectedTitles)
+		/*
+			Unless images are to be selected or named by frame (which requires all of them to be known),
+			stream them into the downloads while their pages are being scraped.
+		*/
+		interactive := flags.Input == "" && len(flags.Titles) == 0 && len(flags.Episodes) == 0
+		streaming = !flags.DryRun && !flags.NoAsync && len(flags.Images) == 0 && !flags.FrameNames && !interactive
 
-	/* Select episodes to scrape from each title. */
-	prompt.SelectEpisodes(selectedTitles, flags.Debug)
+		if !streaming {
+			/* Collect images from the selected titles and episodes. Pages which could not be scraped are reported at the end. */
+			if err := client.ScrapeImages(ctx, selectedTitles); err != nil {
+				exitIfInterrupted(ctx)
+			}
+			if flags.Debug {
+				printFoundImages(selectedTitles)
+			}
 
-	/* Collect images from the selected titles and episodes. */
-	scraper.GetImages(selectedTitles)
+			/* Select images to scrape from each episode range. Prompt only if titles and episodes were also chosen interactively. */
+			prompt.SelectImages(selectedTitles, flags.Images, interactive, flags.Debug)
+		}
+	}
 
 	if flags.DryRun { /* Dry run mode: Print data, don't download anything. */
-		format.OutputFormat(selectedTitles, flags.Format.String())
+		if err := format.OutputFormat(os.Stdout, selectedTitles, flags.Format.String()); err != nil {
+			exitOnError(ctx, err)
+		}
 	} else { /* Download images from the selected titles and episodes. */
-		scraper.DownloadImages(selectedTitles)
+		if newJob {
+			manifest = job.New(flags.OutputDir, selectedTitles) // Replacing an unfinished job was confirmed already.
+			manifest.FrameNames = flags.FrameNames
+		}
+		observer, closeProgress := newProgressObserver(flags, client, selectedTitles, progressOut)
+		if recorder != nil {
+			observer = progress.Multi(observer, recorder)
+		}
+
+		err := client.Download(ctx, selectedTitles, scraper.DownloadOptions{
+			OutputDir:  flags.OutputDir,
+			Manifest:   manifest,
+			Stream:     streaming,
+			Observer:   observer,
+			FrameNames: flags.FrameNames,
+		})
+		closeProgress()
+		if err != nil {
+			exitOnError(ctx, err)
+		}
 	}
+	exitIfInterrupted(ctx)
 
 	/* Print info that may require user attention. Otherwise, indicate success. */
-	logf.PrintStats()
+	exitWithSummary(client, selectedTitles)
+}
+
+/* Returns a new scraper client configured by flags `flags`. */
+func newClient(flags cli.CLIFlags) (*scraper.Client, error) {
+	transport, err := httpclient.New(httpclient.Config{
+		Proxy:           flags.Proxy,
+		UserAgent:       flags.UserAgent,
+		Headers:         flags.Headers,
+		CABundle:        flags.CABundle,
+		ConnectTimeout:  flags.ConnectTimeout,
+		ReadTimeout:     flags.ReadTimeout,
+		MaxConnsPerHost: int(flags.ParallelDownloads) + int(flags.PageParallelism),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []scraper.Option{
+		scraper.WithTransport(transport),
+		scraper.WithAsync(!flags.NoAsync),
+		scraper.WithPageLimits(int(flags.PageParallelism), flags.PageDelay),
+		scraper.WithParallelDownloads(int(flags.ParallelDownloads)),
+		scraper.WithRateLimit(flags.RateMode, flags.Rate, flags.MinRate, flags.MaxRate),
+		scraper.WithBandwidthLimit(flags.LimitRate),
+		scraper.WithRetries(int(flags.Retries), flags.MaxBackoff),
+		scraper.WithRetryPass(flags.RetryPassDelay),
+		scraper.WithLogger(logf.LogErrorf),
+	}
+	if flags.Verbose {
+		opts = append(opts, scraper.WithVerbose(os.Stdout))
+	}
+	if flags.Debug {
+		opts = append(opts, scraper.WithDebug(os.Stdout))
+	}
+
+	return scraper.New(opts...)
 }
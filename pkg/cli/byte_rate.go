@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+/* Multipliers of byte rate suffixes. (Powers of 1024, like curl's --limit-rate) */
+var byteRateUnits = map[string]float64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+}
+
+/* Matches a byte rate, such as "500K", "1.5M" or "2MB/s". */
+var byteRateRe = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([kmg]?)(?:i?b)?(?:/s)?$`)
+
+/* A non-negative rate of bytes per second. */
+type byteRate int64
+
+/*
+Returns a new byte rate value.
+Panics if `val` is less than 0.
+*/
+func newByteRate(val int64, p *int64) *byteRate {
+	if val < 0 {
+		panic("default value for byteRate must be non-negative (got: " + strconv.FormatInt(val, 10) + ")")
+	}
+
+	*p = val
+	return (*byteRate)(p)
+}
+
+/*
+Sets the byte rate `r` to the amount of bytes per second given by the string `s`,
+a number optionally followed by a K, M or G suffix. (e.g., "500K", "1.5M")
+Returns an error, if `s` is not a byte rate or a non-zero rate below 1 byte/second.
+*/
+func (r *byteRate) Set(s string) error {
+	match := byteRateRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if match == nil {
+		return fmt.Errorf("invalid rate %q; expected a number of bytes/second with an optional K, M or G suffix (e.g., 500K, 2M)", s)
+	}
+
+	v, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return err
+	}
+	rate := byteRate(v * byteRateUnits[match[2]])
+	if v > 0 && rate == 0 { // A rate of 0 means unlimited, so a rate below 1 byte/second must not become one.
+		return fmt.Errorf("invalid rate %q; expected at least 1 byte/second, or 0 for unlimited", s)
+	}
+	*r = rate
+
+	return nil
+}
+
+/* Returns the string representation of the byte rate `r`. */
+func (r *byteRate) String() string {
+	return strconv.FormatInt(int64(*r), 10)
+}
+
+/* Returns a string representing the type of byte rate `r`. */
+func (r *byteRate) Type() string {
+	return "rate"
+}
+
+/* Registers a byte rate flag. */
+func ByteRateVar(flagSet *pflag.FlagSet, p *int64, name string, value int64, usage string) {
+	flagSet.Var(newByteRate(value, p), name, usage+" (bytes/second, with an optional K, M or G suffix)")
+}
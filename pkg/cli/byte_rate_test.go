@@ -0,0 +1,65 @@
+package cli
+
+import "testing"
+
+func TestByteRateSet(t *testing.T) {
+	tests := []struct {
+		input     string // Byte rate.
+		expected  int64  // Expected bytes/second.
+		expectErr bool   // True if an error is expected from the given input.
+	}{
+		{"0", 0, false},
+		{"0K", 0, false},
+		{"0.0", 0, false},
+		{"500", 500, false},
+		{"500K", 500 << 10, false},
+		{"500k", 500 << 10, false},
+		{"2M", 2 << 20, false},
+		{"1G", 1 << 30, false},
+		{"1.5M", 3 << 19, false},
+		{"0.5K", 512, false},
+		{"2MB/s", 2 << 20, false},
+		{"1KiB", 1 << 10, false},
+		{"100b", 100, false},
+		{" 100 K ", 100 << 10, false},
+
+		{"", 0, true},
+		{"-1", 0, true},
+		{"-1M", 0, true},
+		{"1T", 0, true},
+		{"1.", 0, true},
+		{".5M", 0, true},
+		{"1,5M", 0, true},
+		{"foo", 0, true},
+		{"1M/min", 0, true},
+		{"0.5", 0, true}, // Below 1 byte/second, but not unlimited.
+		{"0.0001K", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var v int64 = 42
+			r := newByteRate(v, &v)
+
+			err := r.Set(tt.input)
+
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("Set(%q) expected error but got nil", tt.input)
+				}
+				if v != 42 {
+					t.Errorf("Set(%q) changed the rate to %d on error; want 42 (unchanged)", tt.input, v)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Set(%q) returned unexpected error: %v", tt.input, err)
+				return
+			}
+			if v != tt.expected {
+				t.Errorf("Set(%q) = %d; want %d", tt.input, v, tt.expected)
+			}
+		})
+	}
+}
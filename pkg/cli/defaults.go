@@ -5,12 +5,22 @@ import (
 	"time"
 
 	"sheeper.com/fancaps-scraper-go/pkg/format"
+	"sheeper.com/fancaps-scraper-go/pkg/httpclient"
+	"sheeper.com/fancaps-scraper-go/pkg/progress"
+	"sheeper.com/fancaps-scraper-go/pkg/ratelimit"
+	"sheeper.com/fancaps-scraper-go/pkg/scraper"
 	"sheeper.com/fancaps-scraper-go/pkg/types"
 )
 
+const (
+	retryFailedCommand = "retry-failed" // Command downloading only the failed images of a previous run.
+	redacted           = "[redacted]"   // Value shown in place of the value of a sensitive flag.
+)
+
 const (
 	exampleUsage = `Usage:
 	fancaps-scraper-go [OPTIONS]
+	fancaps-scraper-go retry-failed [OPTIONS] <report>
 
 Examples:
 	# Show this message and exit.
@@ -26,12 +36,85 @@ Examples:
   fancaps-scraper -q Inception --categories movies --debug
 
   # Search for "Friends" tv series titles only, with asynchronous network requests explicitly disabled.
-  fancaps-scraper -q Friends --categories tv --no-async`
+  fancaps-scraper -q Friends --categories tv --no-async
+
+  # Scrape the titles "Naruto" and "Inception" directly, without the title menu. (Title names must match exactly.)
+  fancaps-scraper --titles Naruto --titles Inception
+
+  # Scrape a title directly from its URL.
+  fancaps-scraper -t 'https://fancaps.net/anime/showimages.php?3435-Neon_Genesis_Evangelion'
+
+  # Scrape episodes 1-10 of "Naruto" and episodes 1, 3 and 5 of "Bleach", without prompting.
+  fancaps-scraper -t Naruto -t Bleach -e 1-10 -e 1-5:2
+
+  # Same as above, but assigning episode ranges by title name.
+  fancaps-scraper -t Naruto -t Bleach -e Bleach=1-5:2 -e Naruto=1-10
+
+  # Scrape images 1-50 from episodes 2-4 and every other image from episodes 8, 10 and 12 of "Naruto".
+  fancaps-scraper -t Naruto -e 2-4,8-12:2 -I 1-50 -I 1-:2
+
+  # Review the images of "Naruto" in a file, then download them later without scraping again.
+  fancaps-scraper -t Naruto -e 1-3 --dry-run --format json > naruto.json
+  fancaps-scraper --input naruto.json
+
+  # Name images after their frame index, so they sort like on fancaps.net. (e.g., 0001_image.jpg)
+  fancaps-scraper -t Naruto -e 1-3 --frame-names
+
+  # Resume an interrupted download, retrying only pending and failed images.
+  fancaps-scraper --resume output/.fsg-job.json
+
+  # Write a JSON report of the run, then download only the images which failed in it, without scraping again.
+  fancaps-scraper -t Naruto -e 1-3 --report run.json
+  fancaps-scraper retry-failed run.json
+
+  # Likewise, download only the images which failed according to a job manifest.
+  fancaps-scraper retry-failed output/.fsg-job.json
 
-	defaultParallelDownloads uint8         = 10              // Default maximum amount of titles or episodes to download images from in parallel.
-	defaultMinDelay          time.Duration = 1 * time.Second // Default minimum delay after every new image download request.
-	defaultRandDelay         time.Duration = 5 * time.Second // Default maximum random delay after every new image download request.
-	defaultMenuLines         uint8         = 10              // Default number of lines shown in a menu's viewport.
+  # Retry failed images once more 2 minutes after all other downloads, instead of 30 seconds.
+  fancaps-scraper -t Naruto -e 1-3 --retry-pass-delay 2m
+
+  # Download with at most 2 MiB/s of bandwidth, shared by all parallel downloads.
+  fancaps-scraper -t Naruto -e 1-3 --limit-rate 2M
+
+  # Route all requests through a local SOCKS5 proxy, with a custom header.
+  fancaps-scraper -q Naruto --proxy socks5://127.0.0.1:1080 --header 'Accept-Language: en-US'
+
+  # Without a terminal, read queries (up to an empty line), title numbers and episode ranges from stdin lines.
+  printf 'Naruto\n\n1\n1-3\n' | fancaps-scraper > naruto.log
+
+  # Write download progress as JSON lines to a file, e.g. for scripts. (By default, JSON progress goes to stdout.)
+  fancaps-scraper -t Naruto -e 1-3 --progress json --progress-file progress.jsonl
+
+Exit codes:
+  0    Success.
+  1    Any other error. (e.g., invalid flags, unreadable input, unwritable output)
+  2    Downloads were aborted, since the server kept rate-limiting them.
+  3    A search query or title name is empty.
+  4    No titles were found for a search query, title name or input file.
+  5    A page did not have the expected layout. (fancaps.net may have changed)
+  6    A page was answered with an unexpected HTTP status code.
+  7    Some images still failed to download after all retries.
+  130  Interrupted.
+
+  Pages which could not be scraped are listed at the end of a run,
+  which then exits with the code of the first failure.`
+
+	defaultParallelDownloads uint8         = scraper.DefaultParallelDownloads // Default maximum amount of images to download in parallel.
+	defaultRate              float64       = scraper.DefaultRate              // Default (initial) rate of image download requests. (requests/second)
+	defaultMinRate           float64       = scraper.DefaultMinRate           // Default minimum rate of image download requests in adaptive mode. (requests/second)
+	defaultMaxRate           float64       = scraper.DefaultMaxRate           // Default maximum rate of image download requests in adaptive mode. (requests/second)
+	defaultMenuLines         uint8         = 10                               // Default number of lines shown in a menu's viewport.
+	defaultRetries           uint8         = scraper.DefaultRetries           // Default maximum amount of retries for transient image download failures.
+	defaultMaxBackoff        time.Duration = scraper.DefaultMaxBackoff        // Default maximum delay between image download retries.
+	defaultMinDelay          time.Duration = 1 * time.Second                  // Default minimum delay between image requests. (Deprecated. See `--rate`)
+	defaultRandDelay         time.Duration = 5 * time.Second                  // Default maximum random delay between image requests. (Deprecated. See `--rate`)
+	defaultRetryPassDelay    time.Duration = scraper.DefaultRetryPassDelay    // Default delay before retrying failed images once all downloads are done.
+	defaultPageParallelism   uint8         = scraper.DefaultPageParallelism   // Default maximum amount of pages to scrape in parallel.
+	defaultPageDelay         time.Duration = scraper.DefaultPageDelay         // Default delay after every page request of a scraper.
+	defaultConnectTimeout    time.Duration = httpclient.DefaultConnectTimeout // Default maximum time to establish a connection.
+	defaultReadTimeout       time.Duration = httpclient.DefaultReadTimeout    // Default maximum time a connection may stall.
+
+	defaultUserAgent = httpclient.DefaultUserAgent // Default User-Agent header.
 )
 
 var (
@@ -54,5 +137,25 @@ var (
 		"yaml": format.FormatYAML,
 	} // A map from custom enums to formats.
 
+	defaultRateMode = scraper.DefaultRateMode // Default rate limiter mode.
+	enumToRateMode  = map[string]ratelimit.Mode{
+		"fixed":    ratelimit.ModeFixed,
+		"adaptive": ratelimit.ModeAdaptive,
+	} // A map from custom enums to rate limiter modes.
+
+	defaultProgress = progress.ModeBar // Default download progress output.
+	enumToProgress  = map[string]progress.Mode{
+		"bar":   progress.ModeBar,
+		"json":  progress.ModeJSON,
+		"plain": progress.ModePlain,
+	} // A map from custom enums to progress modes.
+
 	defaultOutputDir = filepath.Join(".", "output") // Default output directory.
+
+	defaultHeaders = httpclient.DefaultHeaders() // Default headers sent with all requests.
+
+	sensitiveFlags = map[string]bool{
+		"header": true,
+		"proxy":  true,
+	} // Flags whose values may hold credentials, and are redacted in reports.
 )
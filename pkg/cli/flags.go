@@ -2,29 +2,58 @@ package cli
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/spf13/pflag"
 	"sheeper.com/fancaps-scraper-go/pkg/format"
+	"sheeper.com/fancaps-scraper-go/pkg/httpclient"
+	"sheeper.com/fancaps-scraper-go/pkg/progress"
+	"sheeper.com/fancaps-scraper-go/pkg/ratelimit"
 	"sheeper.com/fancaps-scraper-go/pkg/types"
 )
 
 /* Available CLI Flags. */
 type CLIFlags struct {
-	Queries           []string         // Search queries to scrape from.
-	Categories        []types.Category // Selected categories to search using `Query`.
-	OutputDir         string           // The directory to output images.
-	ParallelDownloads uint8            // Maximum amount of image downloads to make in parallel.
-	MinDelay          time.Duration    // Minimum delay applied after subsequent image requests. (Non-negative)
-	RandDelay         time.Duration    // Maximum random delay applied after subsequent image requests. (Non-negative)
-	MenuLines         uint8            // Number of lines shown in a menu's viewport.
-	Verbose           bool             // If true, explain what is being done.
-	Debug             bool             // If true, print useful debugging messages.
-	NoAsync           bool             // If true, disable asynchronous network requests.
-	NoLog             bool             // If true, disable logging.
-	DryRun            bool             // If true, perform a dry run. (Safe. No changes made.)
-	Format            format.Format    // Format used to print scraped titles.
+	Queries           []string          // Search queries to scrape from.
+	Titles            []string          // Title URLs or exact title names to scrape from. (Skips the title menu)
+	Episodes          []string          // Episode ranges to scrape from each title. (Skips the episode prompt)
+	Images            []string          // Image ranges to scrape from each episode range. (Skips the image prompt)
+	Input             string            // File to read titles from. (JSON, CSV and YAML files skip scraping)
+	Resume            string            // Job manifest to resume downloading pending and failed images from.
+	RetryFailed       string            // Report or job manifest of a previous run to download only its failed images from. (retry-failed command)
+	Categories        []types.Category  // Selected categories to search using `Query`.
+	OutputDir         string            // The directory to output images.
+	FrameNames        bool              // If true, prefix image filenames with their frame index.
+	ParallelDownloads uint8             // Maximum amount of image downloads to make in parallel.
+	Rate              float64           // (Initial) rate of image requests. (requests/second, strictly positive)
+	MinRate           float64           // Minimum rate of image requests in adaptive mode. (requests/second, strictly positive)
+	MaxRate           float64           // Maximum rate of image requests in adaptive mode. (requests/second, strictly positive)
+	LimitRate         int64             // Maximum bytes/second received by all image downloads. (0 means no limit)
+	RateMode          ratelimit.Mode    // Rate limiter mode for image requests.
+	Retries           uint8             // Maximum amount of retries for transient image download failures.
+	MaxBackoff        time.Duration     // Maximum delay between image download retries. (Non-negative)
+	RetryPassDelay    time.Duration     // Delay before retrying failed images once all downloads are done. (Non-negative, 0 disables)
+	PageParallelism   uint8             // Maximum amount of pages (search, episode and image pages) to scrape in parallel.
+	PageDelay         time.Duration     // Delay applied after subsequent page requests of a scraper. (Non-negative)
+	Proxy             string            // Proxy URL for all requests. (Overrides HTTP(S)_PROXY)
+	UserAgent         string            // User-Agent header sent with all requests.
+	Headers           http.Header       // Additional headers sent with all requests.
+	CABundle          string            // PEM file of additional certificate authorities to trust.
+	ConnectTimeout    time.Duration     // Maximum time to establish a connection. (Non-negative, 0 disables)
+	ReadTimeout       time.Duration     // Maximum time a connection may stall. (Non-negative, 0 disables)
+	MenuLines         uint8             // Number of lines shown in a menu's viewport.
+	Verbose           bool              // If true, explain what is being done.
+	Debug             bool              // If true, print useful debugging messages.
+	NoAsync           bool              // If true, disable asynchronous network requests.
+	NoLog             bool              // If true, disable logging.
+	DryRun            bool              // If true, perform a dry run. (Safe. No changes made.)
+	Format            format.Format     // Format used to print scraped titles.
+	Progress          progress.Mode     // How download progress is shown.
+	ProgressFile      string            // File to write download progress to, instead of stdout. (JSON and plain modes only)
+	Report            string            // File to write the JSON report of the run to, once it exits.
+	Used              map[string]string // Flags set on the command line, by name. (Values of sensitive flags are redacted)
 }
 
 var flags CLIFlags // User CLI flags.
@@ -33,18 +62,44 @@ var flags CLIFlags // User CLI flags.
 func ParseCLI() {
 	var (
 		queries           []string
+		titles            []string
+		episodes          []string
+		images            []string
+		input             string
+		resume            string
+		retryFailed       string
 		categories        []types.Category
 		outputDir         string
+		frameNames        bool
 		parallelDownloads uint8
-		minDelay          time.Duration
-		randDelay         time.Duration
+		rate              float64
+		minRate           float64
+		maxRate           float64
+		limitRate         int64
+		rateMode          ratelimit.Mode
+		minDelay          time.Duration // Deprecated. (See `--rate`)
+		randDelay         time.Duration // Deprecated. (See `--rate`)
+		retries           uint8
+		maxBackoff        time.Duration
+		retryPassDelay    time.Duration
+		pageParallelism   uint8
+		pageDelay         time.Duration
+		proxy             string
+		userAgent         string
+		headers           http.Header
+		caBundle          string
+		connectTimeout    time.Duration
+		readTimeout       time.Duration
 		menuLines         uint8
 		verbose           bool
 		debug             bool
 		noAsync           bool
 		noLog             bool
 		dryRun            bool
-		format            format.Format
+		outputFormat      format.Format
+		progressMode      progress.Mode
+		progressFile      string
+		reportFile        string
 	)
 
 	f := pflag.NewFlagSet("fancaps-scraper", pflag.ContinueOnError)
@@ -64,25 +119,59 @@ func ParseCLI() {
 
 	/* Flag Definitions. */
 	f.StringSliceVarP(&queries, "query", "q", []string{}, "Search query terms.")
+	f.StringArrayVarP(&titles, "titles", "t", []string{}, "Title URLs or exact title names to scrape. (Repeatable)")
+	f.StringArrayVarP(&episodes, "episodes", "e", []string{}, "Episode ranges to scrape, for all titles, per title in order, or as title_name=range. (Repeatable)")
+	f.StringArrayVarP(&images, "images", "I", []string{}, "Image ranges to scrape, for all episode ranges, or per comma-separated episode range in order. (Repeatable)")
+	f.StringVarP(&input, "input", "i", "", "File of titles to scrape. (.json, .csv, .yaml from --dry-run, or a list of title URLs/names)")
+	f.StringVar(&resume, "resume", "", "Job manifest (.fsg-job.json in an output directory) to resume pending and failed downloads from.")
 	EnumSliceVarP(f, &categories, "categories", "c", defaultCategories, enumToCategory, "Categories to search.")
 	CreateDirVarP(f, &outputDir, "output-dir", "o", defaultOutputDir, "Output directory for images.")
+	f.BoolVar(&frameNames, "frame-names", false, "Prefix image filenames with their frame index (e.g., 0042_image.jpg), so they sort in the order of the site.")
 	Puint8VarP(f, &parallelDownloads, "parallel-downloads", "p", defaultParallelDownloads, "Maximum concurrent image downloads.")
-	NnDurationVar(f, &minDelay, "min-delay", defaultMinDelay, "Minimum delay between image requests.")
-	NnDurationVar(f, &randDelay, "random-delay", defaultRandDelay, "Maximum random delay between image requests.")
+	Pfloat64Var(f, &rate, "rate", defaultRate, "Initial image requests per second, shared by all downloads.")
+	Pfloat64Var(f, &minRate, "min-rate", defaultMinRate, "Minimum image requests per second in adaptive mode.")
+	Pfloat64Var(f, &maxRate, "max-rate", defaultMaxRate, "Maximum image requests per second in adaptive mode.")
+	ByteRateVar(f, &limitRate, "limit-rate", 0, "Maximum bandwidth shared by all image downloads. (0 means no limit)")
+	EnumVar(f, &rateMode, "rate-mode", defaultRateMode, enumToRateMode, "Image request rate mode. (adaptive: speed up while healthy, back off on rate limits)")
+	NnDurationVar(f, &minDelay, "min-delay", defaultMinDelay, "Minimum delay between the image requests of every parallel download.")
+	NnDurationVar(f, &randDelay, "random-delay", defaultRandDelay, "Maximum random delay between the image requests of every parallel download.")
+	f.MarkDeprecated("min-delay", "use --rate instead (e.g., --min-delay 2s --random-delay 0 -p 10 is --rate 5)")
+	f.MarkDeprecated("random-delay", "use --rate instead (e.g., --min-delay 0 --random-delay 4s -p 10 is --rate 5)")
+	f.Uint8Var(&retries, "retries", defaultRetries, "Maximum retries for transient image download failures. (0 disables retries)")
+	NnDurationVar(f, &maxBackoff, "max-backoff", defaultMaxBackoff, "Maximum delay between image download retries.")
+	NnDurationVar(f, &retryPassDelay, "retry-pass-delay", defaultRetryPassDelay, "Delay before retrying failed images once more, after all other downloads. (0 disables)")
+	Puint8Var(f, &pageParallelism, "page-parallelism", defaultPageParallelism, "Maximum concurrent page requests to fancaps.net.")
+	NnDurationVar(f, &pageDelay, "page-delay", defaultPageDelay, "Delay between page requests, plus up to half of it at random.")
+	f.StringVar(&proxy, "proxy", "", "Proxy URL for all requests. (http, https, socks5 or socks5h; defaults to HTTP(S)_PROXY)")
+	f.StringVar(&userAgent, "user-agent", defaultUserAgent, "User-Agent header sent with all requests.")
+	HeaderVar(f, &headers, "header", defaultHeaders, "Header sent with all requests, as \"Name: Value\". (Repeatable, \"Name:\" removes a header)")
+	f.StringVar(&caBundle, "ca-bundle", "", "PEM file of additional certificate authorities to trust.")
+	NnDurationVar(f, &connectTimeout, "connect-timeout", defaultConnectTimeout, "Maximum time to establish a connection. (0 disables)")
+	NnDurationVar(f, &readTimeout, "read-timeout", defaultReadTimeout, "Maximum time a connection may stall while reading or writing. (0 disables)")
 	Puint8Var(f, &menuLines, "menu-lines", defaultMenuLines, "Number of lines displayed in a menu.")
 	f.BoolVarP(&verbose, "verbose", "v", false, "Display what is being done.")
 	f.BoolVar(&debug, "debug", false, "Display results as stages complete.")
 	f.BoolVar(&noAsync, "no-async", false, "Disable asynchronous requests.")
 	f.BoolVar(&noLog, "no-log", false, "Disable logging.")
 	f.BoolVarP(&dryRun, "dry-run", "n", false, "Do not change anything, only print results.")
-	EnumVar(f, &format, "format", defaultFormat, enumToFormat, "Output format for dry-run.")
+	EnumVar(f, &outputFormat, "format", defaultFormat, enumToFormat, "Output format for dry-run.")
+	EnumVar(f, &progressMode, "progress", defaultProgress, enumToProgress, "Download progress output. (json: one object per event on stdout, with any other output on stderr, plain: one line per completed episode)")
+	f.StringVar(&progressFile, "progress-file", "", "File to write json or plain progress to, instead of stdout.")
+	f.StringVar(&reportFile, "report", "", "File to write a JSON report of the run to, with the outcome of every title and episode.")
 
 	/* Custom help. */
 	var help bool
 	f.BoolVarP(&help, "help", "h", false, "Display this help and exit.")
 
+	/* The retry-failed command takes the job manifest of a previous run, along with the usual flags. */
+	args := os.Args[1:]
+	retryCmd := len(args) > 0 && args[0] == retryFailedCommand
+	if retryCmd {
+		args = args[1:]
+	}
+
 	/* Parse args. */
-	if err := f.Parse(os.Args[1:]); err != nil {
+	if err := f.Parse(args); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -93,20 +182,140 @@ func ParseCLI() {
 		os.Exit(0)
 	}
 
+	if retryCmd {
+		if f.NArg() != 1 {
+			fmt.Printf("command %s requires exactly one report (a --report file or output/.fsg-job.json)\n", retryFailedCommand)
+			os.Exit(1)
+		}
+		retryFailed = f.Arg(0)
+	}
+
+	/* Titles are resolved without searching, so they cannot be used alongside queries. */
+	if len(queries) > 0 && len(titles) > 0 {
+		fmt.Println("flags --query and --titles cannot be used together")
+		os.Exit(1)
+	}
+
+	/* Input files replace both queries and titles. */
+	if input != "" && (len(queries) > 0 || len(titles) > 0) {
+		fmt.Println("flag --input cannot be used together with --query or --titles")
+		os.Exit(1)
+	}
+
+	/* Structured input files already hold their episodes and images, so none are selected. */
+	if input != "" && format.IsStructured(input) && (len(episodes) > 0 || len(images) > 0) {
+		fmt.Println("flags --episodes and --images cannot be used together with a structured --input file (.json, .csv or .yaml)")
+		os.Exit(1)
+	}
+
+	if proxy != "" {
+		if _, err := httpclient.ParseProxy(proxy); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	/* Resumed jobs already hold their titles. */
+	if resume != "" && (len(queries) > 0 || len(titles) > 0 || input != "") {
+		fmt.Println("flag --resume cannot be used together with --query, --titles or --input")
+		os.Exit(1)
+	}
+
+	/* Likewise, retried reports already hold their titles. */
+	if retryFailed != "" && (len(queries) > 0 || len(titles) > 0 || input != "" || resume != "") {
+		fmt.Printf("command %s cannot be used together with --query, --titles, --input or --resume\n", retryFailedCommand)
+		os.Exit(1)
+	}
+
+	if progressFile != "" && progressMode == progress.ModeBar {
+		fmt.Println("flag --progress-file requires --progress json or plain")
+		os.Exit(1)
+	}
+
+	/* Dry runs download nothing to report. */
+	if reportFile != "" && dryRun {
+		fmt.Println("flag --report cannot be used together with --dry-run")
+		os.Exit(1)
+	}
+
+	/*
+		Deprecated delay flags: Every parallel download used to wait for the minimum delay plus a random delay of up to
+		the maximum random delay between its requests. Map their average across all parallel downloads onto a fixed rate,
+		unless another mode was asked for.
+	*/
+	if f.Changed("min-delay") || f.Changed("random-delay") {
+		if f.Changed("rate") {
+			fmt.Println("flags --min-delay and --random-delay cannot be used together with --rate")
+			os.Exit(1)
+		}
+
+		interval := max(minDelay+randDelay/2, time.Millisecond)
+		rate = float64(parallelDownloads) * float64(time.Second) / float64(interval)
+		if !f.Changed("rate-mode") {
+			rateMode = ratelimit.ModeFixed
+		}
+	}
+
+	if minRate > maxRate {
+		fmt.Printf("flag --min-rate (%g) cannot exceed --max-rate (%g)\n", minRate, maxRate)
+		os.Exit(1)
+	}
+
 	/* Assign values. */
 	flags.Queries = queries
+	flags.Titles = titles
+	flags.Episodes = episodes
+	flags.Images = images
+	flags.Input = input
+	flags.Resume = resume
+	flags.RetryFailed = retryFailed
 	flags.Categories = categories
 	flags.OutputDir = outputDir
+	flags.FrameNames = frameNames
 	flags.ParallelDownloads = parallelDownloads
-	flags.MinDelay = minDelay
-	flags.RandDelay = randDelay
+	flags.Rate = rate
+	flags.MinRate = minRate
+	flags.MaxRate = maxRate
+	flags.LimitRate = limitRate
+	flags.RateMode = rateMode
+	flags.Retries = retries
+	flags.MaxBackoff = maxBackoff
+	flags.RetryPassDelay = retryPassDelay
+	flags.PageParallelism = pageParallelism
+	flags.PageDelay = pageDelay
+	flags.Proxy = proxy
+	flags.UserAgent = userAgent
+	flags.Headers = headers
+	flags.CABundle = caBundle
+	flags.ConnectTimeout = connectTimeout
+	flags.ReadTimeout = readTimeout
 	flags.MenuLines = menuLines
 	flags.Verbose = verbose
 	flags.Debug = debug
 	flags.NoAsync = noAsync
 	flags.NoLog = noLog
 	flags.DryRun = dryRun
-	flags.Format = format
+	flags.Format = outputFormat
+	flags.Progress = progressMode
+	flags.ProgressFile = progressFile
+	flags.Report = reportFile
+	flags.Used = usedFlags(f)
+}
+
+/*
+Returns the flags of the flag set `f` which were set on the command line, by name.
+Values of flags which may hold credentials (i.e., headers and proxy URLs) are redacted.
+*/
+func usedFlags(f *pflag.FlagSet) map[string]string {
+	used := make(map[string]string)
+	f.Visit(func(fl *pflag.Flag) {
+		used[fl.Name] = fl.Value.String()
+		if sensitiveFlags[fl.Name] {
+			used[fl.Name] = redacted
+		}
+	})
+
+	return used
 }
 
 /* Returns a copy of the CLI flags. */
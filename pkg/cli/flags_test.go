@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/ratelimit"
+)
+
+/* Parses the command line arguments `args` for the duration of the test `t`, and returns the resulting flags. */
+func parseArgs(t *testing.T, args ...string) CLIFlags {
+	t.Helper()
+
+	oldArgs := os.Args
+	os.Args = append([]string{"fancaps-scraper"}, args...)
+	t.Cleanup(func() { os.Args = oldArgs })
+
+	ParseCLI()
+	return Flags()
+}
+
+func TestDeprecatedDelays(t *testing.T) {
+	tests := []struct {
+		name     string         // Name of the test.
+		args     []string       // Command line arguments.
+		rate     float64        // Expected rate. (requests/second)
+		rateMode ratelimit.Mode // Expected rate mode.
+	}{
+		{"no delays", nil, defaultRate, defaultRateMode},
+		{"min delay", []string{"--min-delay", "2s", "--random-delay", "0", "-p", "1"}, 0.5, ratelimit.ModeFixed},
+		{"min delay, parallel", []string{"--min-delay", "2s", "--random-delay", "0", "-p", "10"}, 5, ratelimit.ModeFixed},
+		{"random delay, parallel", []string{"--min-delay", "0", "--random-delay", "4s", "-p", "4"}, 2, ratelimit.ModeFixed},
+		{"default delays", []string{"--min-delay", "1s"}, float64(defaultParallelDownloads) / 3.5, ratelimit.ModeFixed},
+		{"no delay", []string{"--min-delay", "0", "--random-delay", "0", "-p", "2"}, 2000, ratelimit.ModeFixed}, // At most once per millisecond.
+		{"adaptive", []string{"--min-delay", "1s", "--random-delay", "0", "-p", "3", "--rate-mode", "adaptive"}, 3, ratelimit.ModeAdaptive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseArgs(t, tt.args...)
+			if math.Abs(got.Rate-tt.rate) > 1e-9 || got.RateMode != tt.rateMode {
+				t.Errorf("ParseCLI(%q) = rate %g (%s); want %g (%s)", tt.args, got.Rate, got.RateMode, tt.rate, tt.rateMode)
+			}
+		})
+	}
+}
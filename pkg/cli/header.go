@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/net/http/httpguts"
+)
+
+/* HTTP headers, set one "Name: Value" pair at a time. */
+type headerValue http.Header
+
+/* Returns a new header value, holding a copy of the headers `val`. */
+func newHeaderValue(val http.Header, p *http.Header) *headerValue {
+	*p = val.Clone()
+	return (*headerValue)(p)
+}
+
+/*
+Sets the header described by the string `s` ("Name: Value") in the header value `h`,
+replacing any previous values of the same name. An empty value ("Name:") removes the header.
+Returns any errors encountered.
+*/
+func (h *headerValue) Set(s string) error {
+	name, value, found := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+	if !found || !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+		return fmt.Errorf("invalid header %q; expected \"Name: Value\"", s)
+	}
+
+	if value == "" {
+		http.Header(*h).Del(name)
+	} else {
+		http.Header(*h).Set(name, value)
+	}
+
+	return nil
+}
+
+/* Returns the string representation of the header value `h`. */
+func (h *headerValue) String() string {
+	var headers []string
+	for name, values := range *h {
+		for _, v := range values {
+			headers = append(headers, name+": "+v)
+		}
+	}
+	sort.Strings(headers)
+
+	return "[" + strings.Join(headers, ", ") + "]"
+}
+
+/* Returns a string representing the type of header `h`. */
+func (h *headerValue) Type() string {
+	return "header"
+}
+
+/* Registers a repeatable HTTP header flag. */
+func HeaderVar(flagSet *pflag.FlagSet, p *http.Header, name string, value http.Header, usage string) {
+	flagSet.Var(newHeaderValue(value, p), name, usage)
+}
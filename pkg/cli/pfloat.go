@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/pflag"
+)
+
+/* A strictly positive float64. */
+type pfloat64Value float64
+
+/*
+Returns a new pfloat64 value.
+Panics if `val` is not strictly positive.
+*/
+func newPfloat64Value(val float64, p *float64) *pfloat64Value {
+	if val <= 0 {
+		panic("default value for pfloat64 must be strictly positive (got: " + strconv.FormatFloat(val, 'g', -1, 64) + ")")
+	}
+
+	*p = val
+	return (*pfloat64Value)(p)
+}
+
+/*
+Sets the pfloat64 value `f` to a strictly positive float64 value derived from the string `s`.
+Returns any errors encountered.
+*/
+func (f *pfloat64Value) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	if !(v > 0) {
+		return fmt.Errorf("invalid value %q; must be strictly positive", s)
+	}
+	*f = pfloat64Value(v)
+
+	return nil
+}
+
+/* Returns the string representation of the pfloat64 value `f`. */
+func (f *pfloat64Value) String() string {
+	return strconv.FormatFloat(float64(*f), 'g', -1, 64)
+}
+
+/* Returns a string representing the type of pfloat64 `f`. */
+func (f *pfloat64Value) Type() string {
+	return "float"
+}
+
+/* Registers a strictly positive float64 flag. */
+func Pfloat64Var(flagSet *pflag.FlagSet, p *float64, name string, value float64, usage string) {
+	flagSet.Var(newPfloat64Value(value, p), name, usage+" (> 0)")
+}
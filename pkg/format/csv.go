@@ -1,7 +1,11 @@
 package format
 
 import (
+	"bytes"
 	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	"sheeper.com/fancaps-scraper-go/pkg/types"
@@ -18,8 +22,20 @@ var schema = []string{
 	"Episode Name",
 	"Episode URL",
 	"Image URL",
+	"Frame",
+	"Page URL",
+	"Thumbnail URL",
+	"Status",
+	"Path",
+	"Size",
+	"Checksum",
+	"HTTP Status",
+	"Attempts",
+	"Error",
 }
 
+const requiredColumns = 6 // Columns up to "Image URL" are required. Files written by earlier versions lack the others.
+
 /* Returns a CSV representation of titles `titles`. */
 func (CSVFormatter) Format(titles []*types.Title) ([]byte, error) {
 	var sb strings.Builder
@@ -29,21 +45,39 @@ func (CSVFormatter) Format(titles []*types.Title) ([]byte, error) {
 		return nil, err
 	}
 
+	/* Write a row for each image of `images`, prefixed with the title and episode columns `prefix`. */
+	writeImages := func(prefix []string, images *types.Images) error {
+		for _, img := range images.List() {
+			row := append(slices.Clone(prefix),
+				img.Url,
+				strconv.Itoa(img.Frame),
+				img.PageUrl,
+				img.ThumbUrl,
+				img.Status.String(),
+				img.Path,
+				strconv.FormatInt(img.Size, 10),
+				img.Checksum,
+				strconv.Itoa(img.HTTPStatus),
+				strconv.Itoa(img.Attempts),
+				img.Error,
+			)
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
 	for _, t := range titles {
 		if t.Category == types.CategoryMovie { // Handle movies seperately, since they have no episodes.
-			for _, img := range t.Images.URLs() {
-				row := []string{t.Name, t.Category.String(), t.Url, "", "", img}
-				if err := w.Write(row); err != nil {
-					return nil, err
-				}
+			if err := writeImages([]string{t.Name, t.Category.String(), t.Url, "", ""}, t.Images); err != nil {
+				return nil, err
 			}
 		} else {
 			for _, ep := range t.Episodes {
-				for _, img := range ep.Images.URLs() {
-					row := []string{t.Name, t.Category.String(), t.Url, ep.Name, ep.Url, img}
-					if err := w.Write(row); err != nil {
-						return nil, err
-					}
+				if err := writeImages([]string{t.Name, t.Category.String(), t.Url, ep.Name, ep.Url}, ep.Images); err != nil {
+					return nil, err
 				}
 			}
 		}
@@ -57,6 +91,118 @@ func (CSVFormatter) Format(titles []*types.Title) ([]byte, error) {
 	return []byte(sb.String()), nil
 }
 
+/*
+Returns titles parsed from the CSV representation `data`. (See `Format()`)
+Rows are grouped into titles and episodes by their URLs, in order of first appearance.
+Files written by earlier versions, which lack the columns after "Image URL", are accepted.
+Images without a frame index are numbered in order.
+*/
+func (CSVFormatter) Parse(data []byte) ([]*types.Title, error) {
+	r := csv.NewReader(bytes.NewReader(data)) // All rows must have as many fields as the header.
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || len(rows[0]) < requiredColumns || len(rows[0]) > len(schema) || !slices.Equal(rows[0], schema[:len(rows[0])]) {
+		return nil, fmt.Errorf("missing CSV header: %s", strings.Join(schema, ","))
+	}
+
+	var (
+		titles   []*types.Title
+		titleMap = make(map[string]*types.Title)   // Title URL -> Title.
+		epMap    = make(map[string]*types.Episode) // Episode URL -> Episode.
+	)
+	for _, row := range rows[1:] {
+		titleName, category, titleURL, epName, epURL := row[0], row[1], row[2], row[3], row[4]
+		img, err := parseImageRow(row[5:])
+		if err != nil {
+			return nil, err
+		}
+
+		t, ok := titleMap[titleURL]
+		if !ok {
+			t, err = newTitle(titleName, category, titleURL)
+			if err != nil {
+				return nil, err
+			}
+			titleMap[titleURL] = t
+			titles = append(titles, t)
+		}
+
+		if epURL == "" { // Image belongs to a title without episodes. (e.g., Movies)
+			if err := addTitleImages(t, []*types.Image{img}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		ep, ok := epMap[epURL]
+		if !ok {
+			if ep, err = addEpisode(t, epName, epURL, nil); err != nil {
+				return nil, err
+			}
+			epMap[epURL] = ep
+		}
+		addImages(ep, ep.Images, []*types.Image{img})
+	}
+
+	return titles, nil
+}
+
+/*
+Returns the image of the image columns `fields` of a row, starting at "Image URL".
+Missing columns are left at their zero value. Returns an error, if a column holds an invalid value.
+*/
+func parseImageRow(fields []string) (*types.Image, error) {
+	img := &types.Image{Url: fields[0]}
+
+	/* Returns the `i`-th column of `fields`, or an empty string if it is missing. */
+	field := func(i int) string {
+		if i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+
+	/* Parses the non-empty integer column `i` of `fields` into `n`. */
+	var err error
+	parseInt := func(i int, n *int64) {
+		if s := field(i); s != "" && err == nil {
+			if *n, err = strconv.ParseInt(s, 10, 64); err != nil {
+				err = fmt.Errorf("invalid %s of image %s: %w", schema[requiredColumns-1+i], img.Url, err)
+			}
+		}
+	}
+
+	var frame, size, httpStatus, attempts int64
+	parseInt(1, &frame)
+	parseInt(6, &size)
+	parseInt(8, &httpStatus)
+	parseInt(9, &attempts)
+	if err != nil {
+		return nil, err
+	}
+
+	if s := field(4); s != "" {
+		if img.Status, err = types.ParseImageStatus(s); err != nil {
+			return nil, fmt.Errorf("image %s: %w", img.Url, err)
+		}
+	}
+
+	img.Frame = int(frame)
+	img.PageUrl = field(2)
+	img.ThumbUrl = field(3)
+	img.Path = field(5)
+	img.Size = size
+	img.Checksum = field(7)
+	img.HTTPStatus = int(httpStatus)
+	img.Attempts = int(attempts)
+	img.Error = field(10)
+
+	return img, nil
+}
+
 /* Returns the content type of the CSV formatter. */
 func (CSVFormatter) ContentType() string {
 	return "text/csv"
@@ -18,15 +18,20 @@ var defFmt = DEFFormatter{}
 
 /* Returns the default representation of titles `titles`. */
 func (DEFFormatter) Format(titles []*types.Title) ([]byte, error) {
-	/* Write image strings `images` to `sb` prefixed with `prefix`. */
-	writeImages := func(sb *strings.Builder, prefix string, images []string) {
-		if len(images) == 0 {
+	/* Write the images `images` to `sb` prefixed with `prefix`, along with their frame indexes and statuses (unless pending). */
+	writeImages := func(sb *strings.Builder, prefix string, images *types.Images) {
+		list := images.List()
+		if len(list) == 0 {
 			return
 		}
 
 		sb.WriteString(prefix + "images:\n")
-		for _, img := range images {
-			sb.WriteString(prefix + titleSpacing + img + "\n")
+		for _, img := range list {
+			sb.WriteString(prefix + titleSpacing + strconv.Itoa(img.Frame) + ": " + img.Url)
+			if img.Status != types.ImagePending {
+				sb.WriteString(" [" + img.Status.String() + "]")
+			}
+			sb.WriteString("\n")
 		}
 	}
 
@@ -37,12 +42,12 @@ func (DEFFormatter) Format(titles []*types.Title) ([]byte, error) {
 	sb.WriteString("titles:\n")
 	for _, t := range titles {
 		sb.WriteString(titleSpacing + t.Name + " [" + t.Category.String() + "]: " + t.Url + "\n")
-		writeImages(&sb, titleSpacing, t.Images.URLs())
+		writeImages(&sb, titleSpacing, t.Images)
 
 		sb.WriteString(titleSpacing + "episodes:\n")
 		for _, ep := range t.Episodes {
 			sb.WriteString(episodeSpacing + ep.Name + ": " + ep.Url + "\n")
-			writeImages(&sb, episodeSpacing, ep.Images.URLs())
+			writeImages(&sb, episodeSpacing, ep.Images)
 		}
 	}
 
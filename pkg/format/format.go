@@ -2,11 +2,10 @@ package format
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"strings"
 
 	"sheeper.com/fancaps-scraper-go/pkg/types"
-	"sheeper.com/fancaps-scraper-go/pkg/ui"
 )
 
 /* Formats titles. */
@@ -65,21 +64,21 @@ var contentTypes = func() string {
 	return strings.Join(cts, ", ")
 }()
 
-/* Prints titles `titles` in the format of the content type `contentType`. */
-func OutputFormat(titles []*types.Title, contentType string) {
+/*
+Writes titles `titles` to the writer `w` in the format of the content type `contentType`.
+Returns an error, if the content type is unknown or the titles could not be formatted.
+*/
+func OutputFormat(w io.Writer, titles []*types.Title, contentType string) error {
 	f, ok := ctToFormat[contentType]
 	if !ok {
-		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("unknown content type `%s`")+"\n"+
-			ui.ErrStyle.Render("valid content types: ")+ui.HighlightStyle.Render("%s"),
-			contentType, contentTypes)
-		os.Exit(1)
+		return fmt.Errorf("unknown content type `%s` (valid content types: %s)", contentType, contentTypes)
 	}
 
 	output, err := f.Format(titles)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("format error: %v")+"\n", err)
-		os.Exit(1)
+		return fmt.Errorf("format error: %w", err)
 	}
 
-	fmt.Print(string(output))
+	_, err = w.Write(output)
+	return err
 }
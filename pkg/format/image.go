@@ -0,0 +1,102 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/*
+An Image JSON/YAML object. (See `types.Image`)
+Images may also be given as bare URL strings, as written before image objects existed.
+*/
+type ImageRecord struct {
+	Url        string `json:"url" yaml:"url"`
+	Frame      int    `json:"frame,omitempty" yaml:"frame,omitempty"`
+	PageUrl    string `json:"page_url,omitempty" yaml:"page_url,omitempty"`
+	ThumbUrl   string `json:"thumb_url,omitempty" yaml:"thumb_url,omitempty"`
+	Status     string `json:"status,omitempty" yaml:"status,omitempty"`
+	Path       string `json:"path,omitempty" yaml:"path,omitempty"`
+	Size       int64  `json:"size,omitempty" yaml:"size,omitempty"`
+	Checksum   string `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	HTTPStatus int    `json:"http_status,omitempty" yaml:"http_status,omitempty"`
+	Attempts   int    `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	Error      string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+/* Returns the records of the images `images`, in order of their frame indexes. */
+func newImageRecords(images *types.Images) []ImageRecord {
+	var records []ImageRecord
+	for _, img := range images.List() {
+		records = append(records, ImageRecord{
+			Url:        img.Url,
+			Frame:      img.Frame,
+			PageUrl:    img.PageUrl,
+			ThumbUrl:   img.ThumbUrl,
+			Status:     img.Status.String(),
+			Path:       img.Path,
+			Size:       img.Size,
+			Checksum:   img.Checksum,
+			HTTPStatus: img.HTTPStatus,
+			Attempts:   img.Attempts,
+			Error:      img.Error,
+		})
+	}
+
+	return records
+}
+
+/* Returns the images of the records `records`, or an error if a record has an unknown status. */
+func parseImageRecords(records []ImageRecord) ([]*types.Image, error) {
+	images := make([]*types.Image, 0, len(records))
+	for _, r := range records {
+		status := types.ImagePending
+		if r.Status != "" {
+			var err error
+			if status, err = types.ParseImageStatus(r.Status); err != nil {
+				return nil, fmt.Errorf("image %s: %w", r.Url, err)
+			}
+		}
+
+		images = append(images, &types.Image{
+			PageUrl:    r.PageUrl,
+			ThumbUrl:   r.ThumbUrl,
+			Url:        r.Url,
+			Frame:      r.Frame,
+			Path:       r.Path,
+			Size:       r.Size,
+			Checksum:   r.Checksum,
+			HTTPStatus: r.HTTPStatus,
+			Attempts:   r.Attempts,
+			Status:     status,
+			Error:      r.Error,
+		})
+	}
+
+	return images, nil
+}
+
+/* Decodes the image record `r` from a JSON object or URL string `data`. */
+func (r *ImageRecord) UnmarshalJSON(data []byte) error {
+	var url string
+	if json.Unmarshal(data, &url) == nil {
+		*r = ImageRecord{Url: url}
+		return nil
+	}
+
+	type record ImageRecord // Without methods, to avoid recursion.
+	return json.Unmarshal(data, (*record)(r))
+}
+
+/* Decodes the image record `r` from a YAML mapping or URL string `value`. */
+func (r *ImageRecord) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*r = ImageRecord{Url: value.Value}
+		return nil
+	}
+
+	type record ImageRecord // Without methods, to avoid recursion.
+	return value.Decode((*record)(r))
+}
@@ -2,6 +2,7 @@ package format
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"sheeper.com/fancaps-scraper-go/pkg/types"
 )
@@ -17,14 +18,14 @@ type JSONTitle struct {
 	Category string        `json:"category"`
 	Url      string        `json:"url"`
 	Episodes []JSONEpisode `json:"episodes"`
-	Images   []string      `json:"images,omitempty"`
+	Images   []ImageRecord `json:"images,omitempty"`
 }
 
 /* An Episode JSON object. */
 type JSONEpisode struct {
-	Name   string   `json:"name"`
-	Url    string   `json:"url"`
-	Images []string `json:"images,omitempty"`
+	Name   string        `json:"name"`
+	Url    string        `json:"url"`
+	Images []ImageRecord `json:"images,omitempty"`
 }
 
 type JSONFormatter struct{}
@@ -39,13 +40,13 @@ func (JSONFormatter) Format(titles []*types.Title) ([]byte, error) {
 			Name:     t.Name,
 			Category: t.Category.String(),
 			Url:      t.Url,
-			Images:   t.Images.URLs(),
+			Images:   newImageRecords(t.Images),
 		}
 		for _, ep := range t.Episodes {
 			jsonTitle.Episodes = append(jsonTitle.Episodes, JSONEpisode{
 				Name:   ep.Name,
 				Url:    ep.Url,
-				Images: ep.Images.URLs(),
+				Images: newImageRecords(ep.Images),
 			})
 		}
 		jsonTitles = append(jsonTitles, jsonTitle)
@@ -59,6 +60,41 @@ func (JSONFormatter) Format(titles []*types.Title) ([]byte, error) {
 	return json.MarshalIndent(output, "", "  ")
 }
 
+/* Returns titles parsed from the JSON representation `data`. (See `Format()`) */
+func (JSONFormatter) Parse(data []byte) ([]*types.Title, error) {
+	var input JSONOutput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, err
+	}
+
+	var titles []*types.Title
+	for _, jt := range input.Titles {
+		t, err := newTitle(jt.Name, jt.Category, jt.Url)
+		if err != nil {
+			return nil, err
+		}
+		images, err := parseImageRecords(jt.Images)
+		if err != nil {
+			return nil, fmt.Errorf("title %q: %w", jt.Name, err)
+		}
+		if err := addTitleImages(t, images); err != nil {
+			return nil, err
+		}
+		for _, je := range jt.Episodes {
+			images, err := parseImageRecords(je.Images)
+			if err != nil {
+				return nil, fmt.Errorf("episode %q: %w", je.Name, err)
+			}
+			if _, err := addEpisode(t, je.Name, je.Url, images); err != nil {
+				return nil, err
+			}
+		}
+		titles = append(titles, t)
+	}
+
+	return titles, nil
+}
+
 /* Returns the content type of the JSON formatter. */
 func (JSONFormatter) ContentType() string {
 	return "application/json"
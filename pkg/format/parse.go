@@ -0,0 +1,152 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/* Parses titles. */
+type Parser interface {
+	Parse(data []byte) ([]*types.Title, error) // Defines how the titles are parsed from `data`.
+}
+
+/* Maps file extensions to their corresponding parser. */
+var extToParser = map[string]Parser{
+	".json": jsonFmt,
+	".csv":  csvFmt,
+	".yaml": yamlFmt,
+	".yml":  yamlFmt,
+}
+
+/*
+Returns true, if the file `filename` holds structured title data (i.e., JSON, CSV or YAML),
+and returns false otherwise.
+*/
+func IsStructured(filename string) bool {
+	_, ok := extToParser[strings.ToLower(filepath.Ext(filename))]
+	return ok
+}
+
+/*
+Returns the titles, along with their episodes and image URLs, parsed from the file `filename`.
+The format of the file is determined by its extension, and must be one produced by
+the JSON, CSV or YAML formatters.
+*/
+func ParseFile(filename string) ([]*types.Title, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	p, ok := extToParser[ext]
+	if !ok {
+		return nil, fmt.Errorf("unsupported input file extension `%s` (%s)", ext, filename)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	titles, err := p.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	return titles, nil
+}
+
+/*
+Returns the non-empty lines of the plain text file `filename`, trimmed of surrounding whitespace.
+Lines starting with '#' are treated as comments and skipped.
+*/
+func ReadLines(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return scanLines(f)
+}
+
+/* Returns the non-empty lines read from `r`, like `ReadLines()`. */
+func scanLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+/* Returns a new title named `name` of category `category` with the URL `url`. */
+func newTitle(name, category, url string) (*types.Title, error) {
+	cat, err := types.ParseCategory(category)
+	if err != nil {
+		return nil, fmt.Errorf("title %q: %w", name, err)
+	}
+
+	return &types.Title{
+		Category: cat,
+		Name:     name,
+		Url:      url,
+		Images:   &types.Images{},
+	}, nil
+}
+
+/*
+Adds the images `images` directly to the title `title`.
+Only titles without episodes (e.g., Movies) may hold images directly.
+*/
+func addTitleImages(title *types.Title, images []*types.Image) error {
+	if len(images) == 0 {
+		return nil
+	}
+	if title.Category != types.CategoryMovie {
+		return fmt.Errorf("title %q: only movie titles may have images outside of episodes", title.Name)
+	}
+
+	addImages(title, title.Images, images)
+
+	return nil
+}
+
+/*
+Adds a new episode named `name` with the URL `url` to the title `title`
+and returns it. The images `images` are added to the episode.
+*/
+func addEpisode(title *types.Title, name, url string, images []*types.Image) (*types.Episode, error) {
+	if title.Category == types.CategoryMovie {
+		return nil, fmt.Errorf("title %q: movie titles cannot have episodes", title.Name)
+	}
+
+	episode := &types.Episode{
+		Title:  title,
+		Name:   name,
+		Url:    url,
+		Images: &types.Images{},
+	}
+	addImages(episode, episode.Images, images)
+	title.Episodes = append(title.Episodes, episode)
+
+	return episode, nil
+}
+
+/*
+Adds the images `images` to the images `imgs` of the title or episode `imgCon`.
+Images without a frame index (e.g., from files written before frame indexes existed) are numbered in order.
+*/
+func addImages(imgCon types.ImageContainer, imgs *types.Images, images []*types.Image) {
+	for _, img := range images {
+		imgs.Add(img)
+		imgCon.IncrementImageTotal()
+	}
+}
@@ -0,0 +1,194 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/* Returns a movie title and an anime title with episodes, both with images. */
+func sampleTitles() []*types.Title {
+	movie := &types.Title{
+		Category: types.CategoryMovie,
+		Name:     "Inception",
+		Url:      "https://fancaps.net/movies/MovieImages.php?name=Inception_2010&movieid=1",
+		Images:   &types.Images{},
+	}
+	for _, img := range []string{"https://cdni.fancaps.net/file/fancaps-movieimages/1.jpg", "https://cdni.fancaps.net/file/fancaps-movieimages/2.jpg"} {
+		movie.Images.AddURL(img)
+		movie.IncrementImageTotal()
+	}
+
+	movie.Images.Add(&types.Image{
+		PageUrl:    movie.Url,
+		ThumbUrl:   "https://cdni.fancaps.net/movieimages/3.jpg",
+		Url:        "https://cdni.fancaps.net/file/fancaps-movieimages/3.jpg",
+		Path:       "output/Inception/3.jpg",
+		Size:       1234,
+		Checksum:   "abcdef",
+		HTTPStatus: 200,
+		Attempts:   2,
+		Status:     types.ImageDownloaded,
+	})
+	movie.IncrementImageTotal()
+
+	anime := &types.Title{
+		Category: types.CategoryAnime,
+		Name:     "Naruto, Part 1",
+		Url:      "https://fancaps.net/anime/showimages.php?1-Naruto",
+		Images:   &types.Images{},
+	}
+	for i, name := range []string{"Episode 1 of Naruto", "Episode 2 of Naruto"} {
+		ep := &types.Episode{
+			Title:  anime,
+			Name:   name,
+			Url:    "https://fancaps.net/anime/episodeimages.php?" + string(rune('1'+i)),
+			Images: &types.Images{},
+		}
+		for j := range 3 {
+			ep.Images.AddURL("https://cdni.fancaps.net/file/fancaps-animeimages/" + string(rune('a'+i)) + string(rune('0'+j)) + ".jpg")
+			ep.IncrementImageTotal()
+		}
+		anime.Episodes = append(anime.Episodes, ep)
+	}
+	anime.Episodes[0].SelectImages([]int{2, 3}) // Selected images keep their frame indexes.
+
+	return []*types.Title{movie, anime}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter Formatter
+		parser    Parser
+	}{
+		{"json", jsonFmt, jsonFmt},
+		{"csv", csvFmt, csvFmt},
+		{"yaml", yamlFmt, yamlFmt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.formatter.Format(sampleTitles())
+			if err != nil {
+				t.Fatalf("Format() returned unexpected error: %v", err)
+			}
+
+			titles, err := tt.parser.Parse(want)
+			if err != nil {
+				t.Fatalf("Parse() returned unexpected error: %v", err)
+			}
+
+			got, err := tt.formatter.Format(titles)
+			if err != nil {
+				t.Fatalf("Format() of parsed titles returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("round trip mismatch:\ngot:\n%s\nwant:\n%s", got, want)
+			}
+
+			if n := titles[0].Images.Total(); n != 3 {
+				t.Errorf("movie image total = %d; want 3", n)
+			}
+			if img := titles[0].Images.List()[2]; img.Status != types.ImageDownloaded || img.Checksum != "abcdef" || img.Attempts != 2 {
+				t.Errorf("movie image = %+v; want the downloaded image", img)
+			}
+			if n := titles[1].Episodes[1].Total(); n != 3 {
+				t.Errorf("episode image total = %d; want 3", n)
+			}
+			if titles[1].Episodes[0].Title != titles[1] {
+				t.Errorf("episode does not point back to its title")
+			}
+			if frames := titles[1].Episodes[0].Images.Frames(); !slices.Equal(frames, []int{2, 3}) {
+				t.Errorf("episode frames = %v; want [2 3]", frames)
+			}
+		})
+	}
+}
+
+func TestParseLegacyJSON(t *testing.T) {
+	input := `{"titles":[{"name":"Akira","category":"Movies","url":"u","images":["1.jpg","2.jpg"]}]}`
+
+	titles, err := jsonFmt.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() returned unexpected error: %v", err)
+	}
+	if urls := titles[0].Images.URLs(); !slices.Equal(urls, []string{"1.jpg", "2.jpg"}) {
+		t.Errorf("URLs() = %q; want [\"1.jpg\" \"2.jpg\"]", urls)
+	}
+}
+
+func TestParseLegacyCSV(t *testing.T) {
+	input := "Title Name,Category,Title URL,Episode Name,Episode URL,Image URL\n" +
+		"Akira,Movies,u,,,1.jpg\n" +
+		"Akira,Movies,u,,,2.jpg\n"
+
+	titles, err := csvFmt.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() returned unexpected error: %v", err)
+	}
+	if frames := titles[0].Images.Frames(); !slices.Equal(frames, []int{1, 2}) {
+		t.Errorf("frames = %v; want [1 2]", frames)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser Parser
+		input  string
+	}{
+		{"json unknown category", jsonFmt, `{"titles":[{"name":"a","category":"Cartoons","url":"u"}]}`},
+		{"json episode in movie", jsonFmt, `{"titles":[{"name":"a","category":"Movies","url":"u","episodes":[{"name":"e","url":"v"}]}]}`},
+		{"yaml title images outside movie", yamlFmt, "titles:\n  - name: a\n    category: Anime\n    url: u\n    images: [x]\n"},
+		{"csv missing header", csvFmt, "a,Anime,u,e,v,x\n"},
+		{"json unknown image status", jsonFmt, `{"titles":[{"name":"a","category":"Movies","url":"u","images":[{"url":"x","status":"lost"}]}]}`},
+		{"csv wrong field count", csvFmt, "Title Name,Category,Title URL,Episode Name,Episode URL,Image URL\na,Anime,u\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.parser.Parse([]byte(tt.input)); err == nil {
+				t.Errorf("Parse(%q) expected error but got nil", tt.input)
+			}
+		})
+	}
+}
+
+func TestScanLines(t *testing.T) {
+	tests := []struct {
+		name     string   // Name of the test.
+		input    string   // Text to read.
+		expected []string // Expected lines.
+	}{
+		{"empty", "", nil},
+		{"single line", "Naruto", []string{"Naruto"}},
+		{"trimmed", "  Naruto \r\n\tBleach\n", []string{"Naruto", "Bleach"}},
+		{"blank lines", "\n\nNaruto\n   \nBleach\n\n", []string{"Naruto", "Bleach"}},
+		{"comments", "# Anime\nNaruto\n  # Bleach\nOne Piece # Not a comment\n", []string{"Naruto", "One Piece # Not a comment"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := scanLines(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("scanLines(%q) returned unexpected error: %v", tt.input, err)
+			}
+			if !slices.Equal(lines, tt.expected) {
+				t.Errorf("scanLines(%q) = %q; want %q", tt.input, lines, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadLinesMissingFile(t *testing.T) {
+	if _, err := ReadLines(filepath.Join(t.TempDir(), "missing.txt")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadLines() of a missing file returned %v; want a not-exist error", err)
+	}
+}
@@ -1,6 +1,8 @@
 package format
 
 import (
+	"fmt"
+
 	"gopkg.in/yaml.v3"
 	"sheeper.com/fancaps-scraper-go/pkg/types"
 )
@@ -15,13 +17,13 @@ type YAMLTitle struct {
 	Category string        `yaml:"category"`
 	Url      string        `yaml:"url"`
 	Episodes []YAMLEpisode `yaml:"episodes"`
-	Images   []string      `yaml:"images,omitempty"`
+	Images   []ImageRecord `yaml:"images,omitempty"`
 }
 
 type YAMLEpisode struct {
-	Name   string   `yaml:"name"`
-	Url    string   `yaml:"url"`
-	Images []string `yaml:"images,omitempty"`
+	Name   string        `yaml:"name"`
+	Url    string        `yaml:"url"`
+	Images []ImageRecord `yaml:"images,omitempty"`
 }
 
 type YAMLFormatter struct{}
@@ -36,13 +38,13 @@ func (YAMLFormatter) Format(titles []*types.Title) ([]byte, error) {
 			Name:     t.Name,
 			Category: t.Category.String(),
 			Url:      t.Url,
-			Images:   t.Images.URLs(),
+			Images:   newImageRecords(t.Images),
 		}
 		for _, ep := range t.Episodes {
 			yamlTitle.Episodes = append(yamlTitle.Episodes, YAMLEpisode{
 				Name:   ep.Name,
 				Url:    ep.Url,
-				Images: ep.Images.URLs(),
+				Images: newImageRecords(ep.Images),
 			})
 		}
 		yamlTitles = append(yamlTitles, yamlTitle)
@@ -56,6 +58,41 @@ func (YAMLFormatter) Format(titles []*types.Title) ([]byte, error) {
 	return yaml.Marshal(output)
 }
 
+/* Returns titles parsed from the YAML representation `data`. (See `Format()`) */
+func (YAMLFormatter) Parse(data []byte) ([]*types.Title, error) {
+	var input YAMLOutput
+	if err := yaml.Unmarshal(data, &input); err != nil {
+		return nil, err
+	}
+
+	var titles []*types.Title
+	for _, yt := range input.Titles {
+		t, err := newTitle(yt.Name, yt.Category, yt.Url)
+		if err != nil {
+			return nil, err
+		}
+		images, err := parseImageRecords(yt.Images)
+		if err != nil {
+			return nil, fmt.Errorf("title %q: %w", yt.Name, err)
+		}
+		if err := addTitleImages(t, images); err != nil {
+			return nil, err
+		}
+		for _, ye := range yt.Episodes {
+			images, err := parseImageRecords(ye.Images)
+			if err != nil {
+				return nil, fmt.Errorf("episode %q: %w", ye.Name, err)
+			}
+			if _, err := addEpisode(t, ye.Name, ye.Url, images); err != nil {
+				return nil, err
+			}
+		}
+		titles = append(titles, t)
+	}
+
+	return titles, nil
+}
+
 /* Returns the content type of the YAML formatter. */
 func (YAMLFormatter) ContentType() string {
 	return "application/x-yaml"
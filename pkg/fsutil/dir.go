@@ -2,17 +2,14 @@ package fsutil
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
-
-	"sheeper.com/fancaps-scraper-go/pkg/ui"
 )
 
 /*
 Returns the path to a newly created output directory at `dirname` to store the scraped images.
 This function checks whether the parent directories of `dirname` exist before creating the directory,
-if they do not, this will exit with code 1.
+and returns an error if they do not.
 
 Anime images will be saved to "./`dirname`/<Anime_Title_Name>/<Anime_Episode_Name>/".
 
@@ -20,47 +17,49 @@ TV Series images will be saved to "./`dirname`/<TV_Title_Name>/<TV_Episode_Name>
 
 Movie images will be saved to "./`dirname`/<Movie_Name>/".
 */
-func CreateOutputDir(dirname string) string {
+func CreateOutputDir(dirname string) (string, error) {
 	/* Check (for a second time) that the parent directories still exist. */
 	if !ParentDirsExist(dirname) {
-		fmt.Fprintf(os.Stderr,
-			ui.ErrStyle.Render("CreateOutputDir error: Couldn't find parent directories of `%s`")+"\n"+
-				ui.ErrStyle.Render("Make sure the parent directories still exist at runtime.")+"\n",
-			dirname)
-		os.Exit(1)
+		return "", fmt.Errorf("couldn't find parent directories of `%s` (make sure they still exist at runtime)", dirname)
 	}
 
-	mkdirIfDNE(dirname)
+	if err := mkdirIfDNE(dirname); err != nil {
+		return "", err
+	}
 
-	return dirname
+	return dirname, nil
 }
 
 /*
 Creates a new directory for a title under the name `titleName` in the directory `outDir`.
 The `outDir` directory must exist.
-Returns the path to the newly created title directory.
+Returns the path to the newly created title directory, and any error encountered.
 */
-func CreateTitleDir(outDir string, titleName string) string {
+func CreateTitleDir(outDir string, titleName string) (string, error) {
 	sanitizedTitleName := sanitizeFilename(titleName)
 
 	titleDir := filepath.Join(outDir, sanitizedTitleName)
-	mkdirIfDNE(titleDir)
+	if err := mkdirIfDNE(titleDir); err != nil {
+		return "", err
+	}
 
-	return titleDir
+	return titleDir, nil
 }
 
 /*
 Creates a new directory for an episode under the name `episodeName` in the directory `titleDir`.
 The `titleDir` directory must exist.
-Returns the path to the newly created episode directory.
+Returns the path to the newly created episode directory, and any error encountered.
 */
-func CreateEpisodeDir(titleDir string, episodeName string) string {
+func CreateEpisodeDir(titleDir string, episodeName string) (string, error) {
 	sanitizedEpisodeName := sanitizeFilename(episodeName)
 
 	episodeDir := filepath.Join(titleDir, sanitizedEpisodeName)
-	mkdirIfDNE(episodeDir)
+	if err := mkdirIfDNE(episodeDir); err != nil {
+		return "", err
+	}
 
-	return episodeDir
+	return episodeDir, nil
 }
 
 /*
@@ -84,12 +83,14 @@ func ParentDirsExist(dirPath string) bool {
 
 /*
 Creates directory `dirname`, if it does not already exist.
-If directory creation fails, prints an error and exits with code 1.
+Returns any error encountered while creating the directory.
 */
-func mkdirIfDNE(dirname string) {
+func mkdirIfDNE(dirname string) error {
 	if _, err := os.Stat(dirname); os.IsNotExist(err) {
 		if err := os.Mkdir(dirname, os.ModePerm); err != nil {
-			log.Fatalf("mkdirIfDNE error: %v", err)
+			return fmt.Errorf("failed to create directory: %w", err)
 		}
 	}
+
+	return nil
 }
@@ -1,18 +1,42 @@
 package fsutil
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"strings"
 )
 
+const (
+	partialSuffix = ".fsg-part"             // Suffix of partial files holding images being downloaded.
+	metaSuffix    = partialSuffix + ".json" // Suffix of sidecar files holding what is needed to resume a partial file.
+)
+
+/*
+Returns the filename of the image at URL `url`, which is the last element of `url`.
+If the frame index `frame` is positive, the filename is prefixed with it (e.g., "0042_image.jpg"),
+so that images are listed in the order of the site.
+*/
+func ImageFilename(url string, frame int) string {
+	filename := path.Base(url)
+	if frame > 0 {
+		filename = fmt.Sprintf("%04d_%s", frame, filename)
+	}
+
+	return filename
+}
+
 /*
-Returns whether the image at URL `url` exists in the directory `imgDir`,
+Returns whether the image with filename `imgFilename` exists in the directory `imgDir`,
 as well as the full image path that was checked.
 */
-func ImageExists(imgDir string, url string) (bool, string) {
-	imgFilename := path.Base(url)
+func ImageExists(imgDir string, imgFilename string) (bool, string) {
 	imgPath := filepath.Join(imgDir, imgFilename)
 
 	if _, err := os.Stat(imgPath); err == nil {
@@ -22,6 +46,75 @@ func ImageExists(imgDir string, url string) (bool, string) {
 	return false, imgPath
 }
 
+/*
+Returns the path of the partial file holding an image while it is being downloaded to the path `imgPath`,
+as well as the path of its sidecar file.
+Both files are hidden and marked as partial, so they are never mistaken for a complete image.
+*/
+func PartialImagePaths(imgPath string) (string, string) {
+	partialPath := filepath.Join(filepath.Dir(imgPath), "."+filepath.Base(imgPath)+partialSuffix)
+	return partialPath, partialPath + ".json"
+}
+
+/* Returns the SHA-256 checksum (in hex) and size of the file at path `p`. */
+func FileChecksum(p string) (string, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+/*
+Removes partial image files left behind in the directory `dir` and its subdirectories
+by previously interrupted downloads, which cannot be resumed since their sidecar file is missing,
+as well as sidecar files whose partial file is missing.
+Partial files which can be resumed are kept.
+Returns the paths of the removed files, and the first error encountered, if any.
+*/
+func RemoveStalePartials(dir string) ([]string, error) {
+	var removed []string
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		var counterpart string
+		switch {
+		case strings.HasSuffix(d.Name(), partialSuffix):
+			counterpart = p + ".json"
+		case strings.HasSuffix(d.Name(), metaSuffix):
+			counterpart = strings.TrimSuffix(p, ".json")
+		default:
+			return nil
+		}
+		if _, err := os.Stat(counterpart); err == nil {
+			return nil // Resumable.
+		}
+
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+		removed = append(removed, p)
+
+		return nil
+	})
+
+	return removed, err
+}
+
 /* Returns a safe filename for file creation. */
 func sanitizeFilename(filename string) string {
 	/*
@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	DefaultUserAgent      = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0" // Default User-Agent header.
+	DefaultConnectTimeout = 15 * time.Second                                                                   // Default maximum time to establish a connection.
+	DefaultReadTimeout    = 30 * time.Second                                                                   // Default maximum time a connection may stall.
+)
+
+/* Returns the default headers sent with every request. */
+func DefaultHeaders() http.Header {
+	return http.Header{
+		"Referer": []string{"https://fancaps.net"},
+	}
+}
+
+/* Returns the default configuration of a transport. */
+func DefaultConfig() Config {
+	return Config{
+		UserAgent:      DefaultUserAgent,
+		Headers:        DefaultHeaders(),
+		ConnectTimeout: DefaultConnectTimeout,
+		ReadTimeout:    DefaultReadTimeout,
+	}
+}
+
+/* Configuration of a transport. */
+type Config struct {
+	Proxy           string        // Proxy URL (http, https, socks5 or socks5h). If empty, HTTP(S)_PROXY is used instead.
+	UserAgent       string        // User-Agent header sent with every request. If empty, requests keep their own.
+	Headers         http.Header   // Headers sent with every request, replacing headers of the same name.
+	CABundle        string        // PEM file of additional certificate authorities to trust. (Optional)
+	ConnectTimeout  time.Duration // Maximum time to establish a connection, including the TLS handshake. 0 means no timeout.
+	ReadTimeout     time.Duration // Maximum time a connection may stall while reading or writing. 0 means no timeout.
+	MaxConnsPerHost int           // Maximum idle connections kept open per host.
+}
+
+/* Schemes supported by proxy URLs. */
+var proxySchemes = map[string]struct{}{
+	"http":    {},
+	"https":   {},
+	"socks5":  {},
+	"socks5h": {},
+}
+
+/*
+Returns a new transport configured by `cfg`, meant to be shared by all requests,
+so that connections are pooled across them.
+Returns an error, if the proxy URL is invalid or the CA bundle cannot be loaded.
+*/
+func New(cfg Config) (http.RoundTripper, error) {
+	proxy := http.ProxyFromEnvironment
+	if cfg.Proxy != "" {
+		proxyURL, err := ParseProxy(cfg.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{}
+	if cfg.CABundle != "" {
+		pool, err := loadCABundle(cfg.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	base := &http.Transport{
+		Proxy: proxy,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil || cfg.ReadTimeout == 0 {
+				return conn, err
+			}
+			return &deadlineConn{Conn: conn, timeout: cfg.ReadTimeout}, nil
+		},
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   cfg.ConnectTimeout,
+		ResponseHeaderTimeout: cfg.ReadTimeout,
+		IdleConnTimeout:       cfg.ReadTimeout, // Idle connections would stall past the read timeout anyway.
+		MaxIdleConns:          0,               // No limit, since idle connections are limited per host.
+		MaxIdleConnsPerHost:   max(cfg.MaxConnsPerHost, http.DefaultMaxIdleConnsPerHost),
+		ForceAttemptHTTP2:     true,
+	}
+
+	return &headerTransport{
+		base:      base,
+		userAgent: cfg.UserAgent,
+		headers:   cfg.Headers,
+	}, nil
+}
+
+/*
+Parses the proxy URL `s`.
+Returns an error, if `s` is not a valid URL or its scheme is not supported.
+*/
+func ParseProxy(s string) (*url.URL, error) {
+	proxyURL, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL `%s`: %w", s, err)
+	}
+	if _, ok := proxySchemes[proxyURL.Scheme]; !ok || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL `%s`: expected scheme://host:port with scheme http, https, socks5 or socks5h", s)
+	}
+
+	return proxyURL, nil
+}
+
+/* Returns the system certificate pool, extended with the certificates of the PEM file `filename`. */
+func loadCABundle(filename string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", filename)
+	}
+
+	return pool, nil
+}
+
+/* Adds the configured User-Agent and headers to every request, before passing it to its base transport. */
+type headerTransport struct {
+	base      http.RoundTripper // Transport performing the requests.
+	userAgent string            // User-Agent header. (Optional)
+	headers   http.Header       // Additional headers.
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	/* A transport must not modify the original request. */
+	req = req.Clone(req.Context())
+
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	for name, values := range t.headers {
+		req.Header[name] = values
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+/*
+A connection failing any read or write which stalls for longer than its timeout,
+so that a hung server cannot block a request forever.
+*/
+type deadlineConn struct {
+	net.Conn
+	timeout time.Duration // Maximum time a single read or write may take.
+}
+
+func (c *deadlineConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *deadlineConn) Write(b []byte) (int, error) {
+	if err := c.Conn.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,388 @@
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+const (
+	ManifestName    = ".fsg-job.json" // Filename of the job manifest in an output directory.
+	manifestVersion = 1               // Version of the manifest layout.
+	saveInterval    = time.Second     // Minimum time between two saves triggered by status updates.
+)
+
+var ErrUnfinished = errors.New("unfinished job") // The output directory holds a job with images left to download.
+
+/* Enum for image statuses. */
+type Status string
+
+const (
+	StatusPending    Status = "pending"    // The image was not processed yet, or its download was interrupted.
+	StatusDownloaded Status = "downloaded" // The image was downloaded.
+	StatusSkipped    Status = "skipped"    // The image already existed, so it was not downloaded.
+	StatusFailed     Status = "failed"     // The download of the image failed.
+)
+
+/*
+A persistent record of a download job: the selected titles, episodes and image URLs,
+along with the status of every image. Safe for concurrent status updates.
+*/
+type Manifest struct {
+	Version    int       `json:"version"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+	FrameNames bool      `json:"frame_names,omitempty"` // If true, image filenames are prefixed with their frame index. Kept when resumed, so that files keep their names.
+	Titles     []*Title  `json:"titles"`
+
+	path     string              // Path of the manifest file.
+	titles   map[string]*Title   // Titles by URL.
+	episodes map[string]*Episode // Episodes by URL.
+	images   map[string]*Image   // Images by URL.
+	dirty    bool                // If true, the manifest has unsaved changes.
+	lastSave time.Time           // Last time the manifest was saved.
+	mu       sync.Mutex          // Prevents bad writes from concurrent downloads.
+}
+
+/* A title in a manifest. */
+type Title struct {
+	Name          string     `json:"name"`
+	Category      string     `json:"category"`
+	Url           string     `json:"url"`
+	EpisodeRanges []string   `json:"episode_ranges,omitempty"`
+	Episodes      []*Episode `json:"episodes,omitempty"`
+	Images        []*Image   `json:"images,omitempty"`
+}
+
+/* An episode in a manifest. */
+type Episode struct {
+	Name       string   `json:"name"`
+	Url        string   `json:"url"`
+	RangeIndex int      `json:"range_index"`
+	Images     []*Image `json:"images"`
+}
+
+/* An image in a manifest. */
+type Image struct {
+	Url    string `json:"url"`
+	Frame  int    `json:"frame,omitempty"` // Frame index of the image. (See `types.Images.Frames()`)
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"` // Reason of the last failure. (Failed images only)
+}
+
+/*
+Returns a new manifest of the titles `titles`, with all images pending,
+to be saved as a file named `ManifestName` in the output directory `outputDir`.
+*/
+func New(outputDir string, titles []*types.Title) *Manifest {
+	now := time.Now()
+	m := &Manifest{
+		Version: manifestVersion,
+		Created: now,
+		Updated: now,
+		path:    filepath.Join(outputDir, ManifestName),
+	}
+
+	for _, t := range titles {
+		mt := &Title{
+			Name:          t.Name,
+			Category:      t.Category.String(),
+			Url:           t.Url,
+			EpisodeRanges: t.EpisodeRanges,
+			Images:        newImages(t.Images),
+		}
+		for _, e := range t.Episodes {
+			mt.Episodes = append(mt.Episodes, &Episode{
+				Name:       e.Name,
+				Url:        e.Url,
+				RangeIndex: e.RangeIndex,
+				Images:     newImages(e.Images),
+			})
+		}
+		m.Titles = append(m.Titles, mt)
+	}
+	m.index()
+
+	return m
+}
+
+/* Returns the pending images of the images `images` of a title or episode. */
+func newImages(images *types.Images) []*Image {
+	list := images.List()
+	imgs := make([]*Image, 0, len(list))
+	for _, img := range list {
+		imgs = append(imgs, &Image{Url: img.Url, Frame: img.Frame, Status: StatusPending})
+	}
+
+	return imgs
+}
+
+/*
+Returns the amount of images still pending in the manifest of the output directory `outputDir`,
+which a new manifest would replace.
+Failed images do not count, since a job is done once every image was processed. (See `FailedTitles()` for retrying them)
+Returns 0, if there is no manifest, or if it cannot be read. (i.e., it could not be resumed either)
+*/
+func Unfinished(outputDir string) int {
+	m, err := Load(filepath.Join(outputDir, ManifestName))
+	if err != nil {
+		return 0
+	}
+
+	n := 0
+	for _, img := range m.images {
+		if img.Status == StatusPending {
+			n++
+		}
+	}
+
+	return n
+}
+
+/* Returns the manifest read from the file `filename`. */
+func Load(filename string) (*Manifest, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{path: filename}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", filename, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d in %s (expected %d)", m.Version, filename, manifestVersion)
+	}
+	m.index()
+
+	return m, nil
+}
+
+/* Indexes the titles, episodes and images of the manifest `m` by URL. */
+func (m *Manifest) index() {
+	m.titles = make(map[string]*Title)
+	m.episodes = make(map[string]*Episode)
+	m.images = make(map[string]*Image)
+	for _, t := range m.Titles {
+		m.titles[t.Url] = t
+		for _, img := range t.Images {
+			m.images[img.Url] = img
+		}
+		for _, e := range t.Episodes {
+			m.episodes[e.Url] = e
+			for _, img := range e.Images {
+				m.images[img.Url] = img
+			}
+		}
+	}
+}
+
+/* Returns the output directory of the manifest `m`. (i.e., the directory holding its file) */
+func (m *Manifest) Dir() string {
+	return filepath.Dir(m.path)
+}
+
+/* Returns the path of the file of the manifest `m`. */
+func (m *Manifest) Path() string {
+	return m.path
+}
+
+/*
+Returns the titles of the manifest `m`, keeping only images which are still pending or failed.
+Episodes and titles without such images are left out.
+Returns an error, if a title has an unknown category.
+*/
+func (m *Manifest) PendingTitles() ([]*types.Title, error) {
+	return m.filterTitles((*Image).pending)
+}
+
+/*
+Returns the titles of the manifest `m`, keeping only images which failed to download.
+Episodes and titles without such images are left out.
+Returns an error, if a title has an unknown category.
+*/
+func (m *Manifest) FailedTitles() ([]*types.Title, error) {
+	return m.filterTitles(func(img *Image) bool { return img.Status == StatusFailed })
+}
+
+/*
+Returns the titles of the manifest `m`, keeping only images for which `keep` returns true.
+Episodes and titles without such images are left out.
+Returns an error, if a title has an unknown category.
+*/
+func (m *Manifest) filterTitles(keep func(img *Image) bool) ([]*types.Title, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var titles []*types.Title
+	for _, mt := range m.Titles {
+		cat, err := types.ParseCategory(mt.Category)
+		if err != nil {
+			return nil, fmt.Errorf("title %q: %w", mt.Name, err)
+		}
+
+		t := &types.Title{
+			Category:      cat,
+			Name:          mt.Name,
+			Url:           mt.Url,
+			Images:        &types.Images{},
+			EpisodeRanges: mt.EpisodeRanges,
+		}
+		for _, img := range mt.Images {
+			if keep(img) {
+				t.Images.Add(&types.Image{Url: img.Url, Frame: img.Frame})
+				t.IncrementImageTotal()
+			}
+		}
+		for _, me := range mt.Episodes {
+			e := &types.Episode{
+				Title:      t,
+				Name:       me.Name,
+				Url:        me.Url,
+				Images:     &types.Images{},
+				RangeIndex: me.RangeIndex,
+			}
+			for _, img := range me.Images {
+				if keep(img) {
+					e.Images.Add(&types.Image{Url: img.Url, Frame: img.Frame})
+					e.IncrementImageTotal()
+				}
+			}
+			if len(e.Images.URLs()) > 0 {
+				t.Episodes = append(t.Episodes, e)
+			}
+		}
+
+		if len(t.Images.URLs()) > 0 || len(t.Episodes) > 0 {
+			titles = append(titles, t)
+		}
+	}
+
+	return titles, nil
+}
+
+/* Returns true, if the image `img` still needs to be downloaded. */
+func (img *Image) pending() bool {
+	return img.Status == StatusPending || img.Status == StatusFailed
+}
+
+/*
+Adds the image at URL `url` with frame index `frame` of the image container `imgCon` (a title or episode of the manifest `m`)
+as a pending image, for images found after the manifest was created.
+Images already in `m` and unknown containers are ignored.
+*/
+func (m *Manifest) AddImage(imgCon types.ImageContainer, url string, frame int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.images[url]; exists {
+		return
+	}
+
+	img := &Image{Url: url, Frame: frame, Status: StatusPending}
+	switch c := imgCon.(type) {
+	case *types.Title:
+		t, ok := m.titles[c.Url]
+		if !ok {
+			return
+		}
+		t.Images = append(t.Images, img)
+	case *types.Episode:
+		e, ok := m.episodes[c.Url]
+		if !ok {
+			return
+		}
+		e.Images = append(e.Images, img)
+	default:
+		return
+	}
+
+	m.images[url] = img
+	m.dirty = true
+}
+
+/*
+Sets the status of the image at URL `url` in the manifest `m` to `status`,
+recording the error `err` for failed images. Unknown URLs are ignored.
+
+The manifest is saved, unless it was saved less than a second ago.
+Returns any error encountered while saving.
+*/
+func (m *Manifest) Update(url string, status Status, err error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	img, ok := m.images[url]
+	if !ok {
+		return nil
+	}
+
+	img.Status = status
+	img.Error = ""
+	if status == StatusFailed && err != nil {
+		img.Error = err.Error()
+	}
+	m.dirty = true
+
+	if time.Since(m.lastSave) < saveInterval {
+		return nil
+	}
+
+	return m.save()
+}
+
+/* Saves the manifest `m` to its file, if it has unsaved changes or was never saved. */
+func (m *Manifest) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.dirty && !m.lastSave.IsZero() {
+		return nil
+	}
+
+	return m.save()
+}
+
+/*
+Atomically writes the manifest `m` to its file, through a temporary file in the same directory.
+Callers are expected to hold the lock of `m`.
+*/
+func (m *Manifest) save() (err error) {
+	m.Updated = time.Now()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+
+	m.dirty = false
+	m.lastSave = m.Updated
+
+	return nil
+}
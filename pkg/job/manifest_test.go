@@ -0,0 +1,132 @@
+package job
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+func newTestTitles() []*types.Title {
+	anime := &types.Title{Category: types.CategoryAnime, Name: "Anime", Url: "anime-url", Images: &types.Images{}}
+	for _, name := range []string{"Episode 1", "Episode 2"} {
+		e := &types.Episode{Title: anime, Name: name, Url: name + "-url", Images: &types.Images{}}
+		e.Images.AddURL(name + "/1.jpg")
+		e.Images.AddURL(name + "/2.jpg")
+		anime.Episodes = append(anime.Episodes, e)
+	}
+
+	movie := &types.Title{Category: types.CategoryMovie, Name: "Movie", Url: "movie-url", Images: &types.Images{}}
+	movie.Images.AddURL("movie/1.jpg")
+
+	return []*types.Title{anime, movie}
+}
+
+func TestResume(t *testing.T) {
+	dir := t.TempDir()
+	m := New(dir, newTestTitles())
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save() returned unexpected error: %v", err)
+	}
+
+	m.Update("Episode 1/1.jpg", StatusDownloaded, nil)
+	m.Update("Episode 1/2.jpg", StatusSkipped, nil)
+	m.Update("Episode 2/1.jpg", StatusFailed, errors.New("bad status code: 500"))
+	m.Update("movie/1.jpg", StatusDownloaded, nil)
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save() returned unexpected error: %v", err)
+	}
+
+	loaded, err := Load(filepath.Join(dir, ManifestName))
+	if err != nil {
+		t.Fatalf("Load() returned unexpected error: %v", err)
+	}
+	if loaded.Dir() != dir {
+		t.Errorf("Dir() = %q; want %q", loaded.Dir(), dir)
+	}
+	if got := loaded.images["Episode 2/1.jpg"].Error; got != "bad status code: 500" {
+		t.Errorf("failed image error = %q; want %q", got, "bad status code: 500")
+	}
+
+	titles, err := loaded.PendingTitles()
+	if err != nil {
+		t.Fatalf("PendingTitles() returned unexpected error: %v", err)
+	}
+
+	/* Only the failed and pending images of episode 2 are left. */
+	if len(titles) != 1 || len(titles[0].Episodes) != 1 {
+		t.Fatalf("PendingTitles() returned %d titles; want 1 title with 1 episode", len(titles))
+	}
+	e := titles[0].Episodes[0]
+	if e.Name != "Episode 2" || len(e.Images.URLs()) != 2 || e.Total() != 2 {
+		t.Errorf("pending episode = %q with %d images (total %d); want \"Episode 2\" with 2 images", e.Name, len(e.Images.URLs()), e.Total())
+	}
+
+	/* Only the failed image of episode 2 is left. */
+	failed, err := loaded.FailedTitles()
+	if err != nil {
+		t.Fatalf("FailedTitles() returned unexpected error: %v", err)
+	}
+	if len(failed) != 1 || len(failed[0].Episodes) != 1 {
+		t.Fatalf("FailedTitles() returned %d titles; want 1 title with 1 episode", len(failed))
+	}
+	if urls := failed[0].Episodes[0].Images.URLs(); len(urls) != 1 || urls[0] != "Episode 2/1.jpg" {
+		t.Errorf("failed images = %v; want [Episode 2/1.jpg]", urls)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), ManifestName)); err == nil {
+		t.Errorf("Load() of missing manifest expected error but got nil")
+	}
+}
+
+func TestUnfinished(t *testing.T) {
+	dir := t.TempDir()
+	if n := Unfinished(dir); n != 0 {
+		t.Errorf("Unfinished() without a manifest = %d; want 0", n)
+	}
+
+	m := New(dir, newTestTitles())
+	m.Update("Episode 1/1.jpg", StatusDownloaded, nil)
+	m.Update("Episode 1/2.jpg", StatusFailed, errors.New("bad status code: 500"))
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save() returned unexpected error: %v", err)
+	}
+
+	/* 2 pending images of episode 2 and 1 of the movie. The failed image does not count. */
+	if n := Unfinished(dir); n != 3 {
+		t.Errorf("Unfinished() = %d; want 3", n)
+	}
+
+	/* A job whose images only failed is done, so it may be replaced. */
+	for _, url := range []string{"Episode 2/1.jpg", "Episode 2/2.jpg", "movie/1.jpg"} {
+		m.Update(url, StatusFailed, errors.New("bad status code: 404"))
+	}
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save() returned unexpected error: %v", err)
+	}
+	if n := Unfinished(dir); n != 0 {
+		t.Errorf("Unfinished() of a job with failed images only = %d; want 0", n)
+	}
+}
+
+func TestFrameNames(t *testing.T) {
+	for _, frameNames := range []bool{false, true} {
+		dir := t.TempDir()
+		m := New(dir, newTestTitles())
+		m.FrameNames = frameNames
+		if err := m.Save(); err != nil {
+			t.Fatalf("Save() returned unexpected error: %v", err)
+		}
+
+		loaded, err := Load(filepath.Join(dir, ManifestName))
+		if err != nil {
+			t.Fatalf("Load() returned unexpected error: %v", err)
+		}
+		if loaded.FrameNames != frameNames {
+			t.Errorf("loaded FrameNames = %t; want %t", loaded.FrameNames, frameNames)
+		}
+	}
+}
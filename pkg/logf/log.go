@@ -6,8 +6,6 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
-
-	"sheeper.com/fancaps-scraper-go/pkg/cli"
 )
 
 /* Enum for log severity. */
@@ -28,6 +26,20 @@ var SeverityName = map[LogSeverity]string{
 	LOG_WARNING: "WARNING",
 }
 
+var (
+	logDir  string // Directory of the log file.
+	enabled bool   // If false, logs are discarded.
+)
+
+/*
+Configures logging to a log file in the directory `dir`, created on the first log.
+Logs are discarded, if `enable` is false. Until configured, all logs are discarded.
+*/
+func Configure(dir string, enable bool) {
+	logDir = dir
+	enabled = enable
+}
+
 var (
 	setOnce        sync.Once // Initializes certain logging variables.
 	Logfile        string    // Path to log file. Contains logs of varying severity. Non-empty, if something unexpected happened.
@@ -39,15 +51,13 @@ Appends errors to a log file, as defined by its severity `severity`, format `for
 arguments `args`. Errors are timestamped with nanosecond precision.
 */
 func LogErrorf(logSev LogSeverity, format string, args ...any) {
-	flags := cli.Flags()
-	if flags.NoLog {
+	if !enabled {
 		return
 	}
 
 	setOnce.Do(func() {
 		fileTimestamp := time.Now().Format("2006-01-02_15-04-05.000000000") // Nanosecond precision.
-		LogDir := flags.OutputDir
-		Logfile = filepath.Join(LogDir, "fsg_errors_"+fileTimestamp+".txt")
+		Logfile = filepath.Join(logDir, "fsg_errors_"+fileTimestamp+".txt")
 
 		maxSeverityLen := 0
 		for _, name := range SeverityName {
@@ -60,7 +70,7 @@ func LogErrorf(logSev LogSeverity, format string, args ...any) {
 	f, err := os.OpenFile(Logfile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to open logfile: %v\n", err)
-		os.Exit(1)
+		return
 	}
 	defer f.Close()
 
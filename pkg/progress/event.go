@@ -0,0 +1,81 @@
+package progress
+
+import (
+	"time"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/* An event of a download, as reported to observers. (See `Observer`) */
+type Event interface {
+	Name() string // Returns the name of the event. (e.g., "image_downloaded")
+}
+
+/* The downloads started. */
+type DownloadStarted struct{}
+
+/* The downloads finished, or were aborted with the error `Err`. */
+type DownloadFinished struct {
+	Err error // Error which aborted the downloads. Nil, if none.
+}
+
+/* The first image of a title is about to be downloaded. */
+type TitleStarted struct {
+	Title *types.Title // Title whose download started.
+}
+
+/* A new image was found while scraping, growing the total of its title or episode. (Streaming only) */
+type ImageFound struct {
+	Container types.ImageContainer // Title (Movies) or episode holding the image.
+	URL       string               // URL of the image.
+}
+
+/* An image was downloaded. */
+type ImageDownloaded struct {
+	Container types.ImageContainer // Title (Movies) or episode holding the image.
+	URL       string               // URL of the image.
+	Bytes     int64                // Bytes transferred, across all attempts.
+	Duration  time.Duration        // Time taken by the download, including retries.
+}
+
+/* An image was not downloaded, since its file already exists. */
+type ImageSkipped struct {
+	Container types.ImageContainer // Title (Movies) or episode holding the image.
+	URL       string               // URL of the image.
+	Path      string               // Path of the existing file.
+}
+
+/* The download of an image failed for good. */
+type ImageFailed struct {
+	Container types.ImageContainer // Title (Movies) or episode holding the image.
+	URL       string               // URL of the image.
+	Err       error                // Reason of the failure.
+}
+
+/* The images which failed to download are about to be retried once more, after a delay. */
+type RetryStarted struct {
+	Images int           // Amount of images to retry.
+	Delay  time.Duration // Delay before the first retry.
+}
+
+/* An image which failed to download is retried, undoing its failure. Its outcome is reported once more. */
+type ImageRetrying struct {
+	Container types.ImageContainer // Title (Movies) or episode holding the image.
+	URL       string               // URL of the image.
+}
+
+/* All images of a title or episode were processed, and no more images will be found. */
+type ContainerDone struct {
+	Container types.ImageContainer // Title or episode which is done.
+}
+
+func (DownloadStarted) Name() string  { return "download_started" }
+func (DownloadFinished) Name() string { return "download_finished" }
+func (TitleStarted) Name() string     { return "title_started" }
+func (ImageFound) Name() string       { return "image_found" }
+func (ImageDownloaded) Name() string  { return "image_downloaded" }
+func (ImageSkipped) Name() string     { return "image_skipped" }
+func (ImageFailed) Name() string      { return "image_failed" }
+func (RetryStarted) Name() string     { return "retry_started" }
+func (ImageRetrying) Name() string    { return "image_retrying" }
+func (ContainerDone) Name() string    { return "container_done" }
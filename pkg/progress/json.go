@@ -0,0 +1,133 @@
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/* An observer writing every event as a JSON object on its own line. (JSON lines) */
+type JSONEmitter struct {
+	enc    *json.Encoder  // Encodes events to the writer of the emitter.
+	titles []*types.Title // Titles of the run, whose images are counted.
+	start  time.Time      // Time of the first event.
+	totals Totals         // Running totals of processed images.
+	err    error          // First error encountered while writing. Later events are dropped.
+}
+
+/* A line of JSON output. */
+type jsonEvent struct {
+	Event     string     `json:"event"`
+	Time      time.Time  `json:"time"`
+	ElapsedMs int64      `json:"elapsed_ms"` // Time since the first event.
+	Title     string     `json:"title,omitempty"`
+	Episode   string     `json:"episode,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	Status    string     `json:"status,omitempty"` // Status of the image. (found, downloaded, skipped, failed or retrying)
+	Path      string     `json:"path,omitempty"`
+	Bytes     int64      `json:"bytes,omitempty"`
+	Duration  int64      `json:"duration_ms,omitempty"`
+	Images    int        `json:"images,omitempty"`   // Amount of images to retry. (Retry pass only)
+	Delay     int64      `json:"delay_ms,omitempty"` // Delay before retrying. (Retry pass only)
+	Error     string     `json:"error,omitempty"`
+	Totals    jsonTotals `json:"totals"`
+}
+
+/* Running totals of a line of JSON output. */
+type jsonTotals struct {
+	Downloaded int    `json:"downloaded"`
+	Skipped    int    `json:"skipped"`
+	Failed     int    `json:"failed"`
+	Images     uint32 `json:"images"` // Amount of images found so far.
+	Bytes      int64  `json:"bytes"`
+}
+
+/* Returns an observer writing every event of the download of titles `titles` as a JSON line to the writer `w`. */
+func NewJSONEmitter(w io.Writer, titles []*types.Title) *JSONEmitter {
+	return &JSONEmitter{enc: json.NewEncoder(w), titles: titles}
+}
+
+func (j *JSONEmitter) Notify(e Event) {
+	if j.err != nil {
+		return
+	}
+
+	now := time.Now()
+	if j.start.IsZero() {
+		j.start = now
+	}
+	j.totals.Add(e)
+
+	je := jsonEvent{Event: e.Name(), Time: now, ElapsedMs: now.Sub(j.start).Milliseconds()}
+	switch ev := e.(type) {
+	case DownloadFinished:
+		je.Error = errString(ev.Err)
+	case TitleStarted:
+		je.Title = ev.Title.Name
+	case ImageFound:
+		je.setContainer(ev.Container)
+		je.URL = ev.URL
+		je.Status = "found"
+	case ImageDownloaded:
+		je.setContainer(ev.Container)
+		je.URL = ev.URL
+		je.Status = "downloaded"
+		je.Bytes = ev.Bytes
+		je.Duration = ev.Duration.Milliseconds()
+	case ImageSkipped:
+		je.setContainer(ev.Container)
+		je.URL = ev.URL
+		je.Status = "skipped"
+		je.Path = ev.Path
+	case ImageFailed:
+		je.setContainer(ev.Container)
+		je.URL = ev.URL
+		je.Status = "failed"
+		je.Error = errString(ev.Err)
+	case RetryStarted:
+		je.Images = ev.Images
+		je.Delay = ev.Delay.Milliseconds()
+	case ImageRetrying:
+		je.setContainer(ev.Container)
+		je.URL = ev.URL
+		je.Status = "retrying"
+	case ContainerDone:
+		je.setContainer(ev.Container)
+	}
+
+	je.Totals = jsonTotals{
+		Downloaded: j.totals.Downloaded,
+		Skipped:    j.totals.Skipped,
+		Failed:     j.totals.Failed,
+		Images:     types.SumCounts(j.titles).Total,
+		Bytes:      j.totals.Bytes,
+	}
+
+	j.err = j.enc.Encode(je)
+}
+
+/* Returns the first error encountered by the emitter `j` while writing, if any. */
+func (j *JSONEmitter) Err() error {
+	return j.err
+}
+
+/* Sets the title and episode names of the JSON event `je` from the title or episode `imgCon`. */
+func (je *jsonEvent) setContainer(imgCon types.ImageContainer) {
+	switch c := imgCon.(type) {
+	case *types.Title:
+		je.Title = c.Name
+	case *types.Episode:
+		je.Title = c.Title.Name
+		je.Episode = c.Name
+	}
+}
+
+/* Returns the message of the error `err`, or an empty string if `err` is nil. */
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
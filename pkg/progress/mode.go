@@ -0,0 +1,21 @@
+package progress
+
+/* Enum for the ways progress is shown. */
+type Mode int
+
+const (
+	ModeBar   Mode = iota // Progress bars, redrawn in place. (Terminals only)
+	ModeJSON              // One JSON object per event. (See `JSONEmitter`)
+	ModePlain             // One line per completed episode or movie. (See `PlainPrinter`)
+)
+
+var ModeName = map[Mode]string{
+	ModeBar:   "bar",
+	ModeJSON:  "json",
+	ModePlain: "plain",
+}
+
+/* Convert a progress mode to its corresponding string representation. */
+func (m Mode) String() string {
+	return ModeName[m]
+}
@@ -0,0 +1,64 @@
+package progress
+
+import "sync"
+
+/*
+Receives the events of downloads.
+Events are delivered one at a time, in order, so observers need not be safe for concurrent use.
+Observers should return quickly, since downloads wait for them.
+*/
+type Observer interface {
+	Notify(e Event)
+}
+
+/* An observer calling itself with every event. */
+type ObserverFunc func(e Event)
+
+func (f ObserverFunc) Notify(e Event) {
+	f(e)
+}
+
+/* Returns an observer forwarding every event to all observers `observers`, in order. Nil observers are ignored. */
+func Multi(observers ...Observer) Observer {
+	var obs multi
+	for _, o := range observers {
+		if o != nil {
+			obs = append(obs, o)
+		}
+	}
+
+	return obs
+}
+
+type multi []Observer
+
+func (m multi) Notify(e Event) {
+	for _, o := range m {
+		o.Notify(e)
+	}
+}
+
+/*
+An observer delivering events to its observer one at a time, for events emitted concurrently.
+The zero value discards all events.
+*/
+type Serial struct {
+	observer Observer   // Receives events. (Optional)
+	mu       sync.Mutex // Delivers one event at a time.
+}
+
+/* Returns an observer delivering events to the observer `o` one at a time. */
+func NewSerial(o Observer) *Serial {
+	return &Serial{observer: o}
+}
+
+func (s *Serial) Notify(e Event) {
+	if s == nil || s.observer == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.observer.Notify(e)
+}
@@ -0,0 +1,98 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/*
+An observer printing one plain line per completed episode or movie, and a final line once downloads finish.
+Images retried after their episode or movie was printed get a line of their own.
+Unlike progress bars, lines are never redrawn, so they suit logs and non-interactive terminals.
+*/
+type PlainPrinter struct {
+	w        io.Writer                       // Writer of lines.
+	start    time.Time                       // Time of the first event.
+	totals   Totals                          // Running totals of processed images.
+	counts   map[types.ImageContainer]Totals // Totals of each episode or movie.
+	retrying bool                            // If true, failed images are being retried. (See `RetryStarted`)
+}
+
+/* Returns an observer printing plain lines to the writer `w`. */
+func NewPlainPrinter(w io.Writer) *PlainPrinter {
+	return &PlainPrinter{
+		w:      w,
+		counts: make(map[types.ImageContainer]Totals),
+	}
+}
+
+func (p *PlainPrinter) Notify(e Event) {
+	if p.start.IsZero() {
+		p.start = time.Now()
+	}
+	p.totals.Add(e)
+
+	switch ev := e.(type) {
+	case ImageDownloaded:
+		if p.retrying {
+			fmt.Fprintf(p.w, "Retried %s: %s downloaded\n", containerName(ev.Container), ev.URL)
+			return
+		}
+		p.add(ev.Container, e)
+	case ImageSkipped:
+		p.add(ev.Container, e)
+	case ImageFailed:
+		if p.retrying {
+			fmt.Fprintf(p.w, "Retried %s: %s failed: %v\n", containerName(ev.Container), ev.URL, ev.Err)
+			return
+		}
+		p.add(ev.Container, e)
+	case RetryStarted:
+		p.retrying = true
+		fmt.Fprintf(p.w, "Retrying %d failed image(s) in %s\n", ev.Images, ev.Delay)
+	case ContainerDone:
+		/* Titles with episodes are done along with their last episode, which was printed already. */
+		if t, ok := ev.Container.(*types.Title); ok && len(t.Episodes) > 0 {
+			return
+		}
+
+		name := containerName(ev.Container)
+
+		elapsed := time.Duration(0)
+		if start := ev.Container.GetStart(); !start.IsZero() {
+			elapsed = time.Since(start).Round(time.Second)
+		}
+
+		fmt.Fprintf(p.w, "%s: %s in %s\n", name, formatTotals(p.counts[ev.Container]), elapsed)
+		delete(p.counts, ev.Container)
+	case DownloadFinished:
+		fmt.Fprintf(p.w, "Finished: %s in %s\n", formatTotals(p.totals), time.Since(p.start).Round(time.Second))
+		if ev.Err != nil {
+			fmt.Fprintf(p.w, "Aborted: %v\n", ev.Err)
+		}
+	}
+}
+
+/* Adds the image event `e` to the totals of the title or episode `imgCon`. */
+func (p *PlainPrinter) add(imgCon types.ImageContainer, e Event) {
+	t := p.counts[imgCon]
+	t.Add(e)
+	p.counts[imgCon] = t
+}
+
+/* Returns the name of the title or episode `imgCon` as text. (e.g., "Title - Episode 1") */
+func containerName(imgCon types.ImageContainer) string {
+	if episode, ok := imgCon.(*types.Episode); ok {
+		return episode.Title.Name + " - " + episode.Name
+	}
+
+	return imgCon.GetName()
+}
+
+/* Returns the totals `t` as text. (e.g., "120 downloaded, 3 skipped, 0 failed (12.3 MB)") */
+func formatTotals(t Totals) string {
+	return fmt.Sprintf("%d downloaded, %d skipped, %d failed (%.1f MB)", t.Downloaded, t.Skipped, t.Failed, float64(t.Bytes)/1e6)
+}
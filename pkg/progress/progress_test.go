@@ -0,0 +1,71 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/* Returns the events of a download of two images of an episode, one of which failed. */
+func testEvents() []Event {
+	title := &types.Title{Category: types.CategoryAnime, Name: "Anime", Images: &types.Images{}}
+	episode := &types.Episode{Title: title, Name: "Episode 1", Images: &types.Images{}}
+	title.Episodes = []*types.Episode{episode}
+
+	return []Event{
+		DownloadStarted{},
+		TitleStarted{Title: title},
+		ImageDownloaded{Container: episode, URL: "1.jpg", Bytes: 1000},
+		ImageFailed{Container: episode, URL: "2.jpg", Err: errors.New("bad status code: 404")},
+		ContainerDone{Container: episode},
+		ContainerDone{Container: title},
+		DownloadFinished{},
+	}
+}
+
+func TestJSONEmitter(t *testing.T) {
+	var buf bytes.Buffer
+	emitter := NewJSONEmitter(&buf, nil)
+	for _, e := range testEvents() {
+		emitter.Notify(e)
+	}
+	if err := emitter.Err(); err != nil {
+		t.Fatalf("Err() = %v; want nil", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(testEvents()) {
+		t.Fatalf("got %d lines; want %d", len(lines), len(testEvents()))
+	}
+
+	var failed jsonEvent
+	if err := json.Unmarshal([]byte(lines[3]), &failed); err != nil {
+		t.Fatalf("failed to decode line %q: %v", lines[3], err)
+	}
+	if failed.Event != "image_failed" || failed.Status != "failed" || failed.Title != "Anime" || failed.Episode != "Episode 1" || failed.URL != "2.jpg" {
+		t.Errorf("line 4 = %+v; want failed image 2.jpg of Anime - Episode 1", failed)
+	}
+	if failed.Totals.Downloaded != 1 || failed.Totals.Failed != 1 || failed.Totals.Bytes != 1000 {
+		t.Errorf("line 4 totals = %+v; want 1 downloaded, 1 failed, 1000 bytes", failed.Totals)
+	}
+}
+
+func TestPlainPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	printer := NewPlainPrinter(&buf)
+	for _, e := range testEvents() {
+		printer.Notify(e)
+	}
+
+	want := []string{
+		"Anime - Episode 1: 1 downloaded, 0 skipped, 1 failed (0.0 MB) in 0s",
+		"Finished: 1 downloaded, 0 skipped, 1 failed (0.0 MB) in 0s",
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got lines:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
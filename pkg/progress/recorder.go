@@ -0,0 +1,39 @@
+package progress
+
+import "sync"
+
+/* An observer recording all events, for tests. Safe for concurrent use. */
+type Recorder struct {
+	events []Event    // Recorded events, in order.
+	mu     sync.Mutex // Prevents overlapping "appends" to `events`.
+}
+
+func (r *Recorder) Notify(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, e)
+}
+
+/* Returns the events recorded by the recorder `r`, in order. */
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Event(nil), r.events...)
+}
+
+/* Returns the amount of events named `name` recorded by the recorder `r`. */
+func (r *Recorder) Count(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, e := range r.events {
+		if e.Name() == name {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,29 @@
+package progress
+
+/* Running totals of the images processed so far. */
+type Totals struct {
+	Downloaded int   // Amount of images downloaded.
+	Skipped    int   // Amount of images skipped, since their files already existed.
+	Failed     int   // Amount of images which failed to download.
+	Bytes      int64 // Bytes transferred for downloaded images, across all attempts.
+}
+
+/* Adds the image event `e` to the totals `t`. Other events are ignored. */
+func (t *Totals) Add(e Event) {
+	switch ev := e.(type) {
+	case ImageDownloaded:
+		t.Downloaded++
+		t.Bytes += ev.Bytes
+	case ImageSkipped:
+		t.Skipped++
+	case ImageFailed:
+		t.Failed++
+	case ImageRetrying:
+		t.Failed--
+	}
+}
+
+/* Returns the amount of images processed, i.e., downloaded, skipped or failed. */
+func (t Totals) Processed() int {
+	return t.Downloaded + t.Skipped + t.Failed
+}
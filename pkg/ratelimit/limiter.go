@@ -0,0 +1,189 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/* Enum for limiter modes. */
+type Mode int
+
+const (
+	ModeFixed    Mode = iota // The rate never changes.
+	ModeAdaptive             // The rate adapts to server responses. (AIMD)
+)
+
+var ModeName = map[Mode]string{
+	ModeFixed:    "fixed",
+	ModeAdaptive: "adaptive",
+}
+
+/* Convert a limiter mode enumeration to its corresponding string representation. */
+func (m Mode) String() string {
+	return ModeName[m]
+}
+
+const (
+	increaseStep     = 0.1 // Rate (requests/second) added after every healthy response. (Additive increase)
+	throttleFactor   = 0.5 // Factor applied to the rate after a rate-limit response. (Multiplicative decrease)
+	congestionFactor = 0.8 // Factor applied to the rate after a response with rising latency.
+	latencySpike     = 2.0 // A response is considered slow, if its latency exceeds the average latency by this factor.
+	latencyWeight    = 0.2 // Weight of the latest response in the (exponentially weighted) average latency.
+)
+
+/*
+A token bucket rate limiter, shared by concurrent requests.
+
+In adaptive mode, the rate follows an AIMD (additive increase, multiplicative decrease) scheme:
+it slowly increases while responses are healthy, and sharply decreases when the server
+rate-limits requests or its latency rises.
+*/
+type Limiter struct {
+	mode    Mode          // Limiter mode.
+	rate    float64       // Current rate. (requests/second)
+	minRate float64       // Minimum rate. (Adaptive mode only)
+	maxRate float64       // Maximum rate. (Adaptive mode only)
+	burst   float64       // Maximum amount of requests allowed at once.
+	tokens  float64       // Available tokens. Negative, if requests are waiting for tokens.
+	last    time.Time     // Last time the tokens were refilled.
+	latency time.Duration // Average latency of healthy responses. 0, if unknown.
+	mu      sync.Mutex    // Prevents bad writes from concurrent requests.
+}
+
+/*
+Returns a new limiter in mode `mode`, starting at rate `rate` (requests/second),
+and allowing at most `burst` requests at once.
+In adaptive mode, the rate is kept between `minRate` and `maxRate`.
+*/
+func New(mode Mode, rate, minRate, maxRate float64, burst int) *Limiter {
+	if mode == ModeAdaptive {
+		rate = min(max(rate, minRate), maxRate)
+	}
+
+	return &Limiter{
+		mode:    mode,
+		rate:    rate,
+		minRate: minRate,
+		maxRate: maxRate,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+/* Returns the current rate of the limiter `l`. (requests/second) */
+func (l *Limiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rate
+}
+
+/*
+Waits until the limiter `l` allows another request.
+Returns the context's error, if the context `ctx` was canceled before then.
+*/
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+/*
+Waits until the limiter `l` allows `n` more units (e.g., requests or bytes) at once.
+`n` may exceed the burst size of `l`, in which case the wait is extended accordingly.
+Returns the context's error, if the context `ctx` was canceled before then.
+*/
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		/* Give back the unused tokens. */
+		l.mu.Lock()
+		l.tokens += float64(n)
+		l.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+/*
+Records a healthy response with latency `latency`.
+In adaptive mode, increases the rate of the limiter `l`, unless the latency is rising,
+in which case the rate is decreased instead.
+*/
+func (l *Limiter) OnSuccess(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.mode != ModeAdaptive {
+		return
+	}
+
+	slow := l.latency > 0 && float64(latency) > latencySpike*float64(l.latency)
+
+	/* Update average latency. */
+	if l.latency == 0 {
+		l.latency = latency
+	} else {
+		l.latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(l.latency))
+	}
+
+	if slow {
+		l.setRate(l.rate * congestionFactor)
+	} else {
+		l.setRate(l.rate + increaseStep)
+	}
+}
+
+/*
+Records a rate-limit response (e.g., 429 or 403).
+In adaptive mode, sharply decreases the rate of the limiter `l`.
+*/
+func (l *Limiter) OnThrottle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.mode != ModeAdaptive {
+		return
+	}
+
+	l.setRate(l.rate * throttleFactor)
+}
+
+/*
+Sets the rate of the limiter `l` to `rate`, kept between its minimum and maximum rates.
+Callers are expected to hold the lock of `l`.
+*/
+func (l *Limiter) setRate(rate float64) {
+	l.refill(time.Now()) // Tokens accumulated so far count at the old rate.
+	l.rate = min(max(rate, l.minRate), l.maxRate)
+}
+
+/*
+Adds the tokens accumulated by the limiter `l` since its last refill, up to its burst size.
+Callers are expected to hold the lock of `l`.
+*/
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+
+	l.tokens = min(l.tokens+elapsed*l.rate, l.burst)
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveRate(t *testing.T) {
+	l := New(ModeAdaptive, 1, 0.5, 2, 1)
+
+	l.OnSuccess(100 * time.Millisecond)
+	if got := l.Rate(); got != 1+increaseStep {
+		t.Errorf("rate after healthy response = %v; want %v", got, 1+increaseStep)
+	}
+
+	/* Rising latency decreases the rate. */
+	before := l.Rate()
+	l.OnSuccess(time.Second)
+	if got := l.Rate(); got >= before {
+		t.Errorf("rate after slow response = %v; want less than %v", got, before)
+	}
+
+	/* Rate limits decrease the rate sharply, down to the minimum rate. */
+	for range 10 {
+		l.OnThrottle()
+	}
+	if got := l.Rate(); got != 0.5 {
+		t.Errorf("rate after rate limits = %v; want minimum rate 0.5", got)
+	}
+
+	/* Healthy responses increase the rate, up to the maximum rate. */
+	for range 100 {
+		l.OnSuccess(100 * time.Millisecond)
+	}
+	if got := l.Rate(); got != 2 {
+		t.Errorf("rate after healthy responses = %v; want maximum rate 2", got)
+	}
+}
+
+func TestFixedRate(t *testing.T) {
+	l := New(ModeFixed, 3, 1, 5, 1)
+
+	l.OnThrottle()
+	l.OnSuccess(time.Millisecond)
+	if got := l.Rate(); got != 3 {
+		t.Errorf("fixed rate changed to %v; want 3", got)
+	}
+}
+
+func TestWait(t *testing.T) {
+	l := New(ModeFixed, 1, 1, 1, 2)
+
+	/* Burst requests are allowed immediately. */
+	start := time.Now()
+	for range 2 {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() returned unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("burst requests waited %v; want no wait", elapsed)
+	}
+
+	/* Requests beyond the burst wait, unless canceled. */
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); err == nil {
+		t.Errorf("Wait() with canceled context expected error but got nil")
+	}
+}
+
+func TestReader(t *testing.T) {
+	l := New(ModeFixed, 1000, 0, 0, 1000)
+
+	/* A second worth of bytes is read immediately, and the next 200 bytes take about 200ms. */
+	start := time.Now()
+	r := NewReader(context.Background(), strings.NewReader(strings.Repeat("x", 1200)), l)
+	n, err := io.Copy(io.Discard, r)
+	if err != nil || n != 1200 {
+		t.Fatalf("io.Copy() = %d, %v; want 1200, nil", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("reading 1200 bytes at 1000 bytes/second took %v; want about 200ms", elapsed)
+	}
+}
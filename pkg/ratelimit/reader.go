@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+)
+
+/* A reader whose reads are limited by a limiter counting bytes. (e.g., to cap bandwidth) */
+type Reader struct {
+	ctx     context.Context // Aborts waiting for the limiter.
+	r       io.Reader       // Underlying reader.
+	limiter *Limiter        // Limiter counting bytes. (bytes/second)
+}
+
+/*
+Returns a reader of `r` waiting for the limiter `l` after every read, for as many tokens as bytes were read,
+so that all readers sharing `l` read at most its rate of bytes per second on average.
+Waiting is aborted once the context `ctx` is canceled.
+*/
+func NewReader(ctx context.Context, r io.Reader, l *Limiter) *Reader {
+	return &Reader{ctx: ctx, r: r, limiter: l}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
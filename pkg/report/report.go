@@ -0,0 +1,317 @@
+package report
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"sheeper.com/fancaps-scraper-go/pkg/job"
+	"sheeper.com/fancaps-scraper-go/pkg/progress"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+const reportVersion = 1 // Version of the report layout.
+
+/*
+A structured report of a run: what was downloaded from every title and episode, and what failed.
+Create one with `Recorder.Report()`.
+*/
+type Report struct {
+	Version         int               `json:"version"`
+	RunID           string            `json:"run_id"`
+	Start           time.Time         `json:"start"`
+	End             time.Time         `json:"end"`
+	DurationMs      int64             `json:"duration_ms"`
+	Flags           map[string]string `json:"flags"`                 // Flags set on the command line, by name.
+	Manifest        string            `json:"manifest,omitempty"`    // Path of the job manifest of the run. (See `job.Manifest`)
+	ManifestCreated time.Time         `json:"manifest_created"`      // Creation time of the job manifest of the run, telling it apart from manifests of later runs.
+	FrameNames      bool              `json:"frame_names,omitempty"` // If true, image filenames were prefixed with their frame index.
+	Error           string            `json:"error,omitempty"`       // Error which aborted the downloads, if any.
+	Totals          Counts            `json:"totals"`
+	Titles          []Title           `json:"titles"`
+	FailedPages     []FailedPage      `json:"failed_pages,omitempty"` // Pages which could not be fetched or scraped.
+}
+
+/* Image counts of a run, title or episode. */
+type Counts struct {
+	Total      uint32 `json:"total"`
+	Downloaded uint32 `json:"downloaded"`
+	Skipped    uint32 `json:"skipped"`
+	Failed     uint32 `json:"failed"`
+	Bytes      int64  `json:"bytes"`
+	DurationMs int64  `json:"duration_ms"` // Time from the first to the last processed image. 0, if none was processed.
+}
+
+/* A title of a report. The counts of titles include those of their episodes. */
+type Title struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Url      string `json:"url"`
+	Counts
+	FailedImages []FailedImage `json:"failed_images,omitempty"` // Movies only.
+	Episodes     []Episode     `json:"episodes,omitempty"`
+}
+
+/* An episode of a report. */
+type Episode struct {
+	Name string `json:"name"`
+	Url  string `json:"url"`
+	Counts
+	FailedImages []FailedImage `json:"failed_images,omitempty"`
+}
+
+/* An image which failed to download, along with the reason. */
+type FailedImage struct {
+	Url        string `json:"url"`
+	Frame      int    `json:"frame,omitempty"`
+	Error      string `json:"error"`
+	HTTPStatus int    `json:"http_status,omitempty"` // Status code of the last response. 0, if none was received.
+	Attempts   int    `json:"attempts"`
+}
+
+/* A page which could not be fetched or scraped. */
+type FailedPage struct {
+	Url   string `json:"url"`
+	Error string `json:"error"`
+}
+
+/*
+An observer recording the download events of a run, from which a report is made. (See `Report()`)
+The run starts when the recorder is created.
+*/
+type Recorder struct {
+	runID string                             // Identifier of the run.
+	start time.Time                          // Start time of the run.
+	err   error                              // Error which aborted the downloads, if any.
+	last  map[types.ImageContainer]time.Time // Time of the last processed image of each title and episode.
+}
+
+/* Returns a recorder of a run starting now. */
+func NewRecorder() *Recorder {
+	start := time.Now()
+
+	return &Recorder{
+		runID: newRunID(start),
+		start: start,
+		last:  make(map[types.ImageContainer]time.Time),
+	}
+}
+
+/*
+Returns a new identifier for a run starting at time `start`, made of the time and random hex digits,
+so identifiers sort by time. (e.g., "20240102T150405Z-1a2b3c4d")
+*/
+func newRunID(start time.Time) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+
+	return start.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+func (r *Recorder) Notify(e progress.Event) {
+	switch ev := e.(type) {
+	case progress.ImageDownloaded:
+		r.processed(ev.Container)
+	case progress.ImageSkipped:
+		r.processed(ev.Container)
+	case progress.ImageFailed:
+		r.processed(ev.Container)
+	case progress.DownloadFinished:
+		r.err = ev.Err
+	}
+}
+
+/* Records that an image of the title or episode `imgCon` was processed now. */
+func (r *Recorder) processed(imgCon types.ImageContainer) {
+	now := time.Now()
+	r.last[imgCon] = now
+	r.last[imgCon.GetTitle()] = now
+}
+
+/*
+Returns the report of the run of the recorder `r` downloading titles `titles`, ending now.
+Flags, the job manifest and failed pages are left for the caller to fill in.
+*/
+func (r *Recorder) Report(titles []*types.Title) *Report {
+	end := time.Now()
+	rep := &Report{
+		Version:    reportVersion,
+		RunID:      r.runID,
+		Start:      r.start,
+		End:        end,
+		DurationMs: end.Sub(r.start).Milliseconds(),
+		Flags:      map[string]string{},
+		Titles:     []Title{},
+	}
+	if r.err != nil {
+		rep.Error = r.err.Error()
+	}
+
+	for _, t := range titles {
+		rt := Title{
+			Name:     t.Name,
+			Category: t.Category.String(),
+			Url:      t.Url,
+			Counts:   r.counts(t),
+		}
+		if t.Category == types.CategoryMovie {
+			rt.FailedImages = failedImages(t.Images)
+		}
+		for _, e := range t.Episodes {
+			rt.Episodes = append(rt.Episodes, Episode{
+				Name:         e.Name,
+				Url:          e.Url,
+				Counts:       r.counts(e),
+				FailedImages: failedImages(e.Images),
+			})
+		}
+		rep.Titles = append(rep.Titles, rt)
+
+		rep.Totals.Total += rt.Total
+		rep.Totals.Downloaded += rt.Downloaded
+		rep.Totals.Skipped += rt.Skipped
+		rep.Totals.Failed += rt.Failed
+		rep.Totals.Bytes += rt.Bytes
+	}
+	rep.Totals.DurationMs = rep.DurationMs
+
+	return rep
+}
+
+/* Returns the counts of the title or episode `imgCon`, as recorded by the recorder `r`. */
+func (r *Recorder) counts(imgCon types.ImageContainer) Counts {
+	c := Counts{
+		Total:      imgCon.Total(),
+		Downloaded: imgCon.Downloaded(),
+		Skipped:    imgCon.Skipped(),
+		Failed:     imgCon.Failed(),
+		Bytes:      imgCon.Bytes(),
+	}
+	if last, ok := r.last[imgCon]; ok && !imgCon.GetStart().IsZero() {
+		c.DurationMs = last.Sub(imgCon.GetStart()).Milliseconds()
+	}
+
+	return c
+}
+
+/* Returns the images of `images` which failed to download, in order of their frame indexes. */
+func failedImages(images *types.Images) []FailedImage {
+	var failed []FailedImage
+	for _, img := range images.List() {
+		if img.Status == types.ImageFailed {
+			failed = append(failed, FailedImage{
+				Url:        img.Url,
+				Frame:      img.Frame,
+				Error:      img.Error,
+				HTTPStatus: img.HTTPStatus,
+				Attempts:   img.Attempts,
+			})
+		}
+	}
+
+	return failed
+}
+
+/*
+Returns the titles of the report `r`, holding only the images which failed to download in its run.
+Episodes and titles without such images are left out.
+Returns an error, if a title has an unknown category.
+*/
+func (r *Report) FailedTitles() ([]*types.Title, error) {
+	var titles []*types.Title
+	for _, rt := range r.Titles {
+		cat, err := types.ParseCategory(rt.Category)
+		if err != nil {
+			return nil, fmt.Errorf("title %q: %w", rt.Name, err)
+		}
+
+		t := &types.Title{
+			Category: cat,
+			Name:     rt.Name,
+			Url:      rt.Url,
+			Images:   &types.Images{},
+		}
+		for _, img := range rt.FailedImages {
+			t.Images.Add(&types.Image{Url: img.Url, Frame: img.Frame})
+			t.IncrementImageTotal()
+		}
+		for _, re := range rt.Episodes {
+			if len(re.FailedImages) == 0 {
+				continue
+			}
+
+			e := &types.Episode{
+				Title:  t,
+				Name:   re.Name,
+				Url:    re.Url,
+				Images: &types.Images{},
+			}
+			for _, img := range re.FailedImages {
+				e.Images.Add(&types.Image{Url: img.Url, Frame: img.Frame})
+				e.IncrementImageTotal()
+			}
+			t.Episodes = append(t.Episodes, e)
+		}
+
+		if len(rt.FailedImages) > 0 || len(t.Episodes) > 0 {
+			titles = append(titles, t)
+		}
+	}
+
+	return titles, nil
+}
+
+/*
+Returns the job manifest of the run of the report `r`, if it still records that run.
+Returns false, if the manifest cannot be read, or if a later run replaced it. (i.e., it was created at another time)
+*/
+func (r *Report) LoadManifest() (*job.Manifest, bool) {
+	if r.Manifest == "" {
+		return nil, false
+	}
+
+	m, err := job.Load(r.Manifest)
+	if err != nil || !m.Created.Equal(r.ManifestCreated) {
+		return nil, false
+	}
+
+	return m, true
+}
+
+/* Writes the report `r` to the file `filename` as indented JSON. */
+func (r *Report) Write(filename string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filename, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+/* Returns the report read from the file `filename`, or an error if it is not a report. */
+func Load(filename string) (*Report, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", filename, err)
+	}
+	if r.RunID == "" {
+		return nil, fmt.Errorf("%s is not a report (no run ID)", filename)
+	}
+	if r.Version != reportVersion {
+		return nil, fmt.Errorf("unsupported report version %d in %s (expected %d)", r.Version, filename, reportVersion)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,168 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sheeper.com/fancaps-scraper-go/pkg/job"
+	"sheeper.com/fancaps-scraper-go/pkg/progress"
+	"sheeper.com/fancaps-scraper-go/pkg/scraper"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+func TestReport(t *testing.T) {
+	anime := &types.Title{Category: types.CategoryAnime, Name: "Anime", Url: "anime-url", Images: &types.Images{}, Start: time.Now()}
+	episode := &types.Episode{Title: anime, Name: "Episode 1", Url: "episode-url", Images: &types.Images{}, Start: time.Now()}
+	anime.Episodes = []*types.Episode{episode}
+	episode.Images.Add(&types.Image{Url: "1.jpg", Status: types.ImageDownloaded})
+	episode.Images.Add(&types.Image{Url: "2.jpg", Status: types.ImageFailed, Error: "bad status code: 404", HTTPStatus: 404, Attempts: 1})
+	for range 2 {
+		episode.IncrementImageTotal()
+	}
+	episode.IncrementDownloaded()
+	episode.AddBytes(1000)
+	episode.IncrementFailed()
+
+	r := NewRecorder()
+	r.Notify(progress.ImageDownloaded{Container: episode, URL: "1.jpg", Bytes: 1000})
+	r.Notify(progress.ImageFailed{Container: episode, URL: "2.jpg", Err: errors.New("bad status code: 404")})
+	r.Notify(progress.DownloadFinished{})
+
+	rep := r.Report([]*types.Title{anime})
+	if rep.RunID == "" || rep.End.Before(rep.Start) {
+		t.Errorf("report = %+v; want a run ID and an end after the start", rep)
+	}
+	if len(rep.Titles) != 1 || len(rep.Titles[0].Episodes) != 1 {
+		t.Fatalf("report has %d titles; want 1 title with 1 episode", len(rep.Titles))
+	}
+
+	e := rep.Titles[0].Episodes[0]
+	if e.Total != 2 || e.Downloaded != 1 || e.Failed != 1 || e.Bytes != 1000 {
+		t.Errorf("episode counts = %+v; want 2 total, 1 downloaded, 1 failed, 1000 bytes", e.Counts)
+	}
+	if len(e.FailedImages) != 1 || e.FailedImages[0].Url != "2.jpg" || e.FailedImages[0].Error != "bad status code: 404" {
+		t.Errorf("failed images = %+v; want 2.jpg with its reason", e.FailedImages)
+	}
+	if rep.Totals.Downloaded != 1 || rep.Totals.Failed != 1 || rep.Totals.Bytes != 1000 {
+		t.Errorf("totals = %+v; want 1 downloaded, 1 failed, 1000 bytes", rep.Totals)
+	}
+
+	/* Reports are read back as written. */
+	filename := filepath.Join(t.TempDir(), "report.json")
+	if err := rep.Write(filename); err != nil {
+		t.Fatalf("Write() returned unexpected error: %v", err)
+	}
+	loaded, err := Load(filename)
+	if err != nil {
+		t.Fatalf("Load() returned unexpected error: %v", err)
+	}
+	if loaded.RunID != rep.RunID || loaded.Titles[0].Episodes[0].FailedImages[0].Url != "2.jpg" {
+		t.Errorf("loaded report = %+v; want the written report", loaded)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(manifest, []byte(`{"version": 1, "titles": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(manifest); err == nil {
+		t.Error("Load() of a job manifest returned no error; want an error")
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Load() of a missing file returned no error; want an error")
+	}
+}
+
+func TestRetryFailedFromReport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "image")
+	}))
+	defer srv.Close()
+
+	/* A run fails to download the second image of a movie... */
+	newMovie := func(name string, urls ...string) *types.Title {
+		movie := &types.Title{Category: types.CategoryMovie, Name: name, Url: name + "-url", Images: &types.Images{}}
+		for _, url := range urls {
+			movie.Images.AddURL(url)
+			movie.IncrementImageTotal()
+		}
+		return movie
+	}
+	dir := t.TempDir()
+	movie := newMovie("Movie", srv.URL+"/1.jpg", srv.URL+"/2.jpg")
+	images := movie.Images.List()
+	images[0].Status = types.ImageDownloaded
+	movie.IncrementDownloaded()
+	images[1].Status = types.ImageFailed
+	images[1].Error = "bad status code: 500"
+	movie.IncrementFailed()
+
+	manifest := job.New(dir, []*types.Title{movie})
+	if err := manifest.Save(); err != nil {
+		t.Fatalf("Save() returned unexpected error: %v", err)
+	}
+	rep := NewRecorder().Report([]*types.Title{movie})
+	rep.Manifest = manifest.Path()
+	rep.ManifestCreated = manifest.Created
+	filename := filepath.Join(t.TempDir(), "report.json")
+	if err := rep.Write(filename); err != nil {
+		t.Fatalf("Write() returned unexpected error: %v", err)
+	}
+	if loaded, err := Load(filename); err != nil {
+		t.Fatalf("Load() returned unexpected error: %v", err)
+	} else if _, ok := loaded.LoadManifest(); !ok {
+		t.Error("LoadManifest() = false; want the manifest of the run")
+	}
+
+	/* ...and a later run into the same directory replaces its manifest. */
+	later := job.New(dir, []*types.Title{newMovie("Other", srv.URL+"/3.jpg")})
+	later.Created = manifest.Created.Add(time.Minute)
+	if err := later.Save(); err != nil {
+		t.Fatalf("Save() returned unexpected error: %v", err)
+	}
+
+	loaded, err := Load(filename)
+	if err != nil {
+		t.Fatalf("Load() returned unexpected error: %v", err)
+	}
+	if _, ok := loaded.LoadManifest(); ok {
+		t.Error("LoadManifest() = true for a replaced manifest; want false")
+	}
+
+	/* The report still retries its own failed image. */
+	titles, err := loaded.FailedTitles()
+	if err != nil {
+		t.Fatalf("FailedTitles() returned unexpected error: %v", err)
+	}
+	if len(titles) != 1 || titles[0].Name != "Movie" || titles[0].Total() != 1 {
+		t.Fatalf("FailedTitles() = %d titles; want the movie with 1 image", len(titles))
+	}
+	if img := titles[0].Images.List()[0]; img.Url != srv.URL+"/2.jpg" || img.Frame != 2 {
+		t.Errorf("failed image = %+v; want %s with frame 2", img, srv.URL+"/2.jpg")
+	}
+
+	c, err := scraper.New(scraper.WithRetries(0, 0), scraper.WithRetryPass(0))
+	if err != nil {
+		t.Fatalf("New() returned unexpected error: %v", err)
+	}
+	retry := job.New(dir, titles)
+	if err := c.Download(context.Background(), titles, scraper.DownloadOptions{Manifest: retry}); err != nil {
+		t.Fatalf("Download() returned unexpected error: %v", err)
+	}
+	if titles[0].Downloaded() != 1 {
+		t.Errorf("Downloaded() = %d; want 1", titles[0].Downloaded())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Movie", "2.jpg")); err != nil {
+		t.Errorf("retried image file: %v", err)
+	}
+}
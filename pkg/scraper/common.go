@@ -1,21 +1,428 @@
 package scraper
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
 	"github.com/gocolly/colly"
-	"sheeper.com/fancaps-scraper-go/pkg/cli"
+	"sheeper.com/fancaps-scraper-go/pkg/httpclient"
+	"sheeper.com/fancaps-scraper-go/pkg/logf"
+	"sheeper.com/fancaps-scraper-go/pkg/ratelimit"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
 )
 
 const allowedDomains = "fancaps.net" // Domains the scraper is allowed to visit.
 
-/* Returns the scraper options from flags `flags`. */
-func GetScraperOpts(flags cli.CLIFlags) []func(*colly.Collector) {
-	scraperOpts := []func(*colly.Collector){
-		colly.AllowedDomains(allowedDomains),
+const (
+	DefaultPageParallelism   = 4                      // Default maximum amount of pages to scrape in parallel.
+	DefaultPageDelay         = 1 * time.Second        // Default delay after every page request of a scraper.
+	DefaultParallelDownloads = 10                     // Default maximum amount of images to download in parallel.
+	DefaultRateMode          = ratelimit.ModeAdaptive // Default rate limiter mode.
+	DefaultRate              = 1.0                    // Default (initial) rate of image download requests. (requests/second)
+	DefaultMinRate           = 0.1                    // Default minimum rate of image download requests in adaptive mode. (requests/second)
+	DefaultMaxRate           = 5.0                    // Default maximum rate of image download requests in adaptive mode. (requests/second)
+	DefaultRetries           = 3                      // Default maximum amount of retries for transient image download failures.
+	DefaultMaxBackoff        = 1 * time.Minute        // Default maximum delay between image download retries.
+	DefaultRetryPassDelay    = 30 * time.Second       // Default delay before retrying failed images once all downloads are done.
+)
+
+/* Receives the logs of a client, as defined by their severity `severity`, format `format` and its arguments `args`. */
+type Logger func(severity logf.LogSeverity, format string, args ...any)
+
+/*
+A fancaps.net scraper, safe for concurrent use. Create one with `New()`.
+
+All requests of a client go through a single transport, and the client limits the amount of pages
+scraped at once and the rate of image downloads across all of its calls.
+Nothing is printed, unless verbose or debug output is enabled. (See `WithVerbose()` and `WithDebug()`)
+*/
+type Client struct {
+	transport         http.RoundTripper  // Transport shared by all collectors and image downloads.
+	async             bool               // If true, pages and images are requested concurrently.
+	pageParallelism   int                // Maximum amount of pages to scrape in parallel.
+	pageDelay         time.Duration      // Delay after every page request of a scraper.
+	pageSlots         chan struct{}      // Limits the amount of collectors scraping pages at once.
+	parallelDownloads int                // Maximum amount of images to download in parallel.
+	rateMode          ratelimit.Mode     // Mode of the image request rate limiter.
+	rate              float64            // (Initial) rate of image requests. (requests/second)
+	minRate           float64            // Minimum rate of image requests in adaptive mode. (requests/second)
+	maxRate           float64            // Maximum rate of image requests in adaptive mode. (requests/second)
+	policy            retryPolicy        // Policy for retrying transient download failures.
+	retryPassDelay    time.Duration      // Delay before retrying failed images once all downloads are done. 0, if disabled.
+	limiter           *ratelimit.Limiter // Limits the rate of image requests across all downloads.
+	bandwidth         int64              // Maximum bytes/second received by all downloads. 0, if unlimited.
+	bandwidthLimiter  *ratelimit.Limiter // Limits the bytes received by all downloads. Nil, if unlimited.
+	log               Logger             // Receives logs. (Never nil)
+	verbose           io.Writer          // Receives verbose output. Nil, if disabled.
+	debug             io.Writer          // Receives debug output. Nil, if disabled.
+	failures          []PageFailure      // Pages which could not be fetched or scraped. (See `FailedPages()`)
+	failuresMu        sync.Mutex         // Prevents overlapping "appends" to `failures`.
+}
+
+/* Configures a client. (See `New()`) */
+type Option func(*Client)
+
+/*
+Returns a new client configured by the options `opts`.
+Unless given a transport, the client creates its own with the defaults of the httpclient package.
+Returns an error, if the client could not be configured.
+*/
+func New(opts ...Option) (*Client, error) {
+	c := &Client{
+		async:             true,
+		pageParallelism:   DefaultPageParallelism,
+		pageDelay:         DefaultPageDelay,
+		parallelDownloads: DefaultParallelDownloads,
+		rateMode:          DefaultRateMode,
+		rate:              DefaultRate,
+		minRate:           DefaultMinRate,
+		maxRate:           DefaultMaxRate,
+		policy: retryPolicy{
+			retries:    DefaultRetries,
+			maxBackoff: DefaultMaxBackoff,
+		},
+		retryPassDelay: DefaultRetryPassDelay,
+		log:            func(logf.LogSeverity, string, ...any) {},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.pageParallelism < 1 || c.parallelDownloads < 1 {
+		return nil, fmt.Errorf("page parallelism and parallel downloads must be at least 1")
+	}
+	if c.bandwidth < 0 {
+		return nil, fmt.Errorf("bandwidth limit cannot be negative")
+	}
+	if !(c.rate > 0) { // Also rejects NaN.
+		return nil, fmt.Errorf("rate must be greater than 0")
+	}
+	if c.rateMode == ratelimit.ModeAdaptive && (!(c.minRate > 0) || !(c.maxRate >= c.minRate)) {
+		return nil, fmt.Errorf("min rate must be greater than 0 and cannot exceed max rate")
+	}
+
+	if c.transport == nil {
+		cfg := httpclient.DefaultConfig()
+		cfg.MaxConnsPerHost = c.parallelDownloads + c.pageParallelism
+		t, err := httpclient.New(cfg)
+		if err != nil {
+			return nil, err
+		}
+		c.transport = t
+	}
+
+	c.pageSlots = make(chan struct{}, c.pageParallelism)
+	c.limiter = ratelimit.New(c.rateMode, c.rate, c.minRate, c.maxRate, 1)
+	if c.bandwidth > 0 {
+		/* Allow up to a second worth of bytes at once. */
+		c.bandwidthLimiter = ratelimit.New(ratelimit.ModeFixed, float64(c.bandwidth), 0, 0, int(c.bandwidth))
+	}
+
+	return c, nil
+}
+
+/* Sends all requests through the transport `rt`, which should be shared by all requests. (See `httpclient.New()`) */
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) { c.transport = rt }
+}
+
+/* Requests pages and images concurrently, if `async` is true (the default), and one at a time otherwise. */
+func WithAsync(async bool) Option {
+	return func(c *Client) { c.async = async }
+}
+
+/*
+Scrapes at most `parallelism` pages at once, waiting for `delay` (plus up to half of it, at random)
+after every page request of a scraper.
+*/
+func WithPageLimits(parallelism int, delay time.Duration) Option {
+	return func(c *Client) {
+		c.pageParallelism = parallelism
+		c.pageDelay = delay
+	}
+}
+
+/* Downloads at most `n` images at once. */
+func WithParallelDownloads(n int) Option {
+	return func(c *Client) { c.parallelDownloads = n }
+}
+
+/*
+Limits image requests to `rate` requests/second, in the limiter mode `mode`.
+In adaptive mode, `rate` is the initial rate, which stays between `minRate` and `maxRate`.
+*/
+func WithRateLimit(mode ratelimit.Mode, rate, minRate, maxRate float64) Option {
+	return func(c *Client) {
+		c.rateMode = mode
+		c.rate = rate
+		c.minRate = minRate
+		c.maxRate = maxRate
+	}
+}
+
+/* Limits the bytes received by all image downloads to `bytesPerSecond` on average. 0 means no limit. */
+func WithBandwidthLimit(bytesPerSecond int64) Option {
+	return func(c *Client) { c.bandwidth = bytesPerSecond }
+}
+
+/* Retries transient image download failures up to `retries` times, waiting at most `maxBackoff` between attempts. */
+func WithRetries(retries int, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.policy = retryPolicy{
+			retries:    retries,
+			maxBackoff: maxBackoff,
+		}
+	}
+}
+
+/*
+Retries images which failed to download once more after all other downloads are done, waiting `delay` beforehand.
+0 disables the retry pass.
+*/
+func WithRetryPass(delay time.Duration) Option {
+	return func(c *Client) { c.retryPassDelay = delay }
+}
+
+/* Sends logs (e.g., skipped files, retries and failed downloads) to `log`. By default, logs are discarded. */
+func WithLogger(log Logger) Option {
+	return func(c *Client) {
+		if log != nil {
+			c.log = log
+		}
+	}
+}
+
+/* Writes verbose output (e.g., visited pages and found images) to `w`. */
+func WithVerbose(w io.Writer) Option {
+	return func(c *Client) { c.verbose = w }
+}
+
+/* Writes debug output (e.g., search query URLs) to `w`. */
+func WithDebug(w io.Writer) Option {
+	return func(c *Client) { c.debug = w }
+}
+
+/* Returns the current rate of image requests of the client `c`. (requests/second) */
+func (c *Client) Rate() float64 {
+	return c.limiter.Rate()
+}
+
+/*
+Returns a new collector sending its requests through the transport of the client `c`.
+
+The collector requests one page at a time, waiting for the page delay of `c` (plus up to half
+of it, at random) after every request. See `withPageSlot()` for limiting the amount of collectors at once.
+Requests made by the collector are aborted once the context `ctx` is canceled.
+*/
+func (c *Client) newCollector(ctx context.Context) *colly.Collector {
+	opts := []func(*colly.Collector){
+		colly.AllowedDomains(allowedDomains, "www."+allowedDomains), // Title URLs may name either host. (See `isTitleURL()`)
+	}
+	if c.async {
+		opts = append(opts, colly.Async(true))
+	}
+
+	col := colly.NewCollector(opts...)
+	col.WithTransport(c.transport)
+
+	/*
+		Note: A limit rule must not be shared between collectors,
+		since colly resets its internal state whenever it is applied to a collector.
+	*/
+	col.Limit(&colly.LimitRule{
+		DomainGlob:  "*" + allowedDomains,
+		Parallelism: 1,
+		Delay:       c.pageDelay,
+		RandomDelay: c.pageDelay / 2,
+	})
+
+	col.OnRequest(func(req *colly.Request) {
+		if ctx.Err() != nil {
+			req.Abort()
+		}
+	})
+
+	return col
+}
+
+const (
+	attemptKey = "fsg-attempt" // Key of the attempt number of a page request in its colly context. (0 for the first attempt)
+	pageKey    = "fsg-page"    // Key of the page number of a page request in its colly context. (0 for the first page)
+)
+
+/*
+Visits the page at URL `url` with the collector `col`, as the page following the page of the request `r`.
+Every page gets its own colly context, so that its page number and attempts are kept apart from other pages.
+*/
+func visitNextPage(col *colly.Collector, r *colly.Request, url string) {
+	ctx := colly.NewContext()
+	ctx.Put(pageKey, pageNumber(r)+1)
+	col.Request("GET", url, nil, ctx, nil)
+}
+
+/* Returns the page number of the request `r`. (0-based, in order of pagination) See `visitNextPage()` */
+func pageNumber(r *colly.Request) int {
+	page, _ := r.Ctx.GetAny(pageKey).(int)
+	return page
+}
+
+/* Returns the position of the element `e` among the elements matched on its page. */
+func elementPosition(e *colly.HTMLElement) types.Position {
+	return types.Position{Page: pageNumber(e.Request), Index: e.Index}
+}
+
+/*
+Visits the URL `url` with the collector `col`, waiting for all of its requests to finish.
+Every collector is expected to be visited this way, exactly once. (Further pages are visited through `col` itself.)
+
+Requests of `col` failing with a transient error (rate limits, server errors, timeouts and connection resets)
+are retried according to the retry policy of the client `c`, unless the context `ctx` is canceled.
+Requests failing for good are recorded as failed pages. (See `FailedPages()`)
+
+Returns the first error encountered by `col`, as an `ErrBadStatus` for responses with an unexpected status code.
+*/
+func (c *Client) visit(ctx context.Context, col *colly.Collector, url string) error {
+	var (
+		firstErr error
+		errMu    sync.Mutex // Prevents overlapping writes to `firstErr` from concurrent requests.
+	)
+
+	col.OnResponse(func(res *colly.Response) {
+		if attempt, _ := res.Ctx.GetAny(attemptKey).(int); attempt > 0 {
+			c.log(logf.LOG_WARNING, "Fetched page (%s) after %d attempt(s)", res.Request.URL, attempt+1)
+		}
+	})
+
+	col.OnError(func(res *colly.Response, err error) {
+		pageURL := res.Request.URL.String()
+
+		var retryAfter time.Duration
+		if res.StatusCode >= 203 { // Same threshold as colly.
+			err = &ErrBadStatus{Code: res.StatusCode, URL: pageURL}
+			if res.Headers != nil {
+				retryAfter = parseRetryAfter(res.Headers.Get("Retry-After"), time.Now())
+			}
+		} else {
+			err = fmt.Errorf("failed to fetch page %s: %w", pageURL, err)
+		}
+
+		if ctx.Err() != nil {
+			return // Interrupted. Aborted requests are not failures.
+		}
+
+		/* Retry transient failures. */
+		var statusErr *ErrBadStatus
+		transient := isTransientError(err) || (errors.As(err, &statusErr) && isTransientStatus(statusErr.Code))
+		attempt, _ := res.Ctx.GetAny(attemptKey).(int)
+		if transient && attempt < c.policy.retries {
+			delay := c.policy.backoff(attempt+1, retryAfter)
+			c.log(logf.LOG_WARNING, "Retrying page (%s) in %s [%d/%d]: %v", pageURL, delay.Round(time.Millisecond), attempt+1, c.policy.retries, err)
+			if sleep(ctx, delay) != nil {
+				return // Interrupted while waiting.
+			}
+
+			res.Ctx.Put(attemptKey, attempt+1)
+			retryErr := res.Request.Retry()
+			if retryErr == nil {
+				return
+			}
+			err = fmt.Errorf("failed to retry page %s: %w", pageURL, retryErr)
+		}
+
+		c.log(logf.LOG_ERROR, "Failed to fetch page (%s) after %d attempt(s): %v", pageURL, attempt+1, err)
+		c.recordFailure(pageURL, err)
+
+		errMu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		errMu.Unlock()
+	})
+
+	if err := col.Visit(url); err != nil {
+		err = fmt.Errorf("failed to visit page %s: %w", url, err)
+		c.recordFailure(url, err)
+		return err
 	}
 
-	if !flags.NoAsync {
-		scraperOpts = append(scraperOpts, colly.Async(true))
+	if c.async {
+		col.Wait()
 	}
 
-	return scraperOpts
+	errMu.Lock()
+	defer errMu.Unlock()
+
+	return firstErr
+}
+
+/* A page which could not be fetched or scraped. */
+type PageFailure struct {
+	URL string // URL of the page.
+	Err error  // Reason of the failure.
+}
+
+/*
+Returns the pages which could not be fetched or scraped by the client `c` so far, in the order they failed.
+Any title, episode or image found on these pages is missing from the results of `c`.
+*/
+func (c *Client) FailedPages() []PageFailure {
+	c.failuresMu.Lock()
+	defer c.failuresMu.Unlock()
+
+	return slices.Clone(c.failures)
+}
+
+/* Records the page at URL `url` as failed with the error `err`, unless it was already recorded. */
+func (c *Client) recordFailure(url string, err error) {
+	c.failuresMu.Lock()
+	defer c.failuresMu.Unlock()
+
+	for _, f := range c.failures {
+		if f.URL == url {
+			return
+		}
+	}
+	c.failures = append(c.failures, PageFailure{URL: url, Err: err})
+}
+
+/*
+Runs `scrape` once a page slot is free, limiting the amount of collectors scraping
+pages at once to the page parallelism of the client `c`, across all of its calls.
+Returns the error of the context `ctx` without running `scrape`, if `ctx` is canceled first.
+Otherwise, returns the error of `ctx` (if canceled meanwhile) or the error of `scrape`.
+*/
+func (c *Client) withPageSlot(ctx context.Context, scrape func() error) error {
+	select {
+	case c.pageSlots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-c.pageSlots }()
+
+	err := scrape()
+	if ctx.Err() != nil {
+		return ctx.Err() // Aborted requests are not failures.
+	}
+
+	return err
+}
+
+/* Writes verbose output to the client `c`, formatted like `fmt.Printf()`, if enabled. */
+func (c *Client) verbosef(format string, args ...any) {
+	if c.verbose != nil {
+		fmt.Fprintf(c.verbose, format, args...)
+	}
+}
+
+/* Writes debug output to the client `c`, formatted like `fmt.Printf()`, if enabled. */
+func (c *Client) debugf(format string, args ...any) {
+	if c.debug != nil {
+		fmt.Fprintf(c.debug, format, args...)
+	}
 }
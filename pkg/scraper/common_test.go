@@ -0,0 +1,247 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/ratelimit"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/* Answers every request with a fixed status code and HTML body, without any network access. */
+type stubTransport struct {
+	code int    // Status code of responses.
+	body string // Body of responses.
+}
+
+func (t stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode:    t.code,
+		Header:        http.Header{"Content-Type": []string{"text/html"}},
+		Body:          io.NopCloser(strings.NewReader(t.body)),
+		ContentLength: int64(len(t.body)),
+		Request:       req,
+	}, nil
+}
+
+/* Answers the first `failures` requests with a 503 status code, and every other request like `stubTransport`. */
+type flakyTransport struct {
+	stubTransport
+	failures int32        // Amount of requests to fail.
+	requests atomic.Int32 // Amount of requests so far.
+}
+
+func (t *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.requests.Add(1) <= t.failures {
+		return stubTransport{code: http.StatusServiceUnavailable}.RoundTrip(req)
+	}
+	return t.stubTransport.RoundTrip(req)
+}
+
+/* Returns a client for tests without retries, answering every request with the status code `code` and HTML body `body`. */
+func newTestClient(t *testing.T, code int, body string) *Client {
+	c, err := New(WithTransport(stubTransport{code: code, body: body}), WithPageLimits(1, 0), WithRetries(0, 0), WithRetryPass(0))
+	if err != nil {
+		t.Fatalf("New() returned unexpected error: %v", err)
+	}
+
+	return c
+}
+
+func TestSearchErrors(t *testing.T) {
+	ctx := context.Background()
+
+	var statusErr *ErrBadStatus
+	_, err := newTestClient(t, http.StatusInternalServerError, "").Search(ctx, "Naruto", nil)
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusInternalServerError {
+		t.Errorf("Search() error = %v; want ErrBadStatus with code 500", err)
+	}
+
+	if _, err := newTestClient(t, http.StatusOK, "<html></html>").Search(ctx, "Naruto", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Search() error = %v; want ErrNotFound", err)
+	}
+
+	titles, err := newTestClient(t, http.StatusOK, `<h4><a href="/anime/showimages.php?1-Naruto">Naruto</a></h4>`).Search(ctx, "Naruto", nil)
+	if err != nil || len(titles) != 1 || titles[0].Category != types.CategoryAnime {
+		t.Errorf("Search() = %v, %v; want one anime title", titles, err)
+	}
+}
+
+func TestLayoutChanged(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, http.StatusOK, "<html><body>Redesigned!</body></html>")
+	title := &types.Title{Category: types.CategoryAnime, Name: "Naruto", Url: "https://fancaps.net/anime/showimages.php?1-Naruto", Images: &types.Images{}}
+
+	if _, err := c.Episodes(ctx, title); !errors.Is(err, ErrLayoutChanged) {
+		t.Errorf("Episodes() error = %v; want ErrLayoutChanged", err)
+	}
+
+	episode := &types.Episode{Title: title, Name: "Episode 1", Url: "https://fancaps.net/anime/episodeimages.php?1", Images: &types.Images{}}
+	if _, err := c.Images(ctx, episode); !errors.Is(err, ErrLayoutChanged) {
+		t.Errorf("Images() error = %v; want ErrLayoutChanged", err)
+	}
+}
+
+func TestPageRetries(t *testing.T) {
+	ctx := context.Background()
+	body := `<h4><a href="/movies/MovieImages.php?name=Akira&movieid=1">Akira</a></h4>`
+
+	/* Transient failures are retried. */
+	rt := &flakyTransport{stubTransport: stubTransport{code: http.StatusOK, body: body}, failures: 2}
+	c, _ := New(WithTransport(rt), WithPageLimits(1, 0), WithRetries(2, 0))
+	if titles, err := c.Search(ctx, "Akira", nil); err != nil || len(titles) != 1 {
+		t.Errorf("Search() = %v, %v; want one title after retrying", titles, err)
+	}
+	if got := rt.requests.Load(); got != 3 {
+		t.Errorf("Search() made %d requests; want 3", got)
+	}
+	if failed := c.FailedPages(); len(failed) != 0 {
+		t.Errorf("FailedPages() = %v; want none", failed)
+	}
+
+	/* Failures outlasting the retries are recorded. */
+	rt = &flakyTransport{stubTransport: stubTransport{code: http.StatusOK, body: body}, failures: 10}
+	c, _ = New(WithTransport(rt), WithPageLimits(1, 0), WithRetries(1, 0))
+	var statusErr *ErrBadStatus
+	if _, err := c.Search(ctx, "Akira", nil); !errors.As(err, &statusErr) {
+		t.Errorf("Search() error = %v; want ErrBadStatus", err)
+	}
+	if failed := c.FailedPages(); len(failed) != 1 || !strings.HasPrefix(failed[0].URL, "https://fancaps.net/search.php") {
+		t.Errorf("FailedPages() = %v; want the search page", failed)
+	}
+}
+
+/* Answers requests with the HTML body of their "page" query parameter, like `stubTransport`. */
+type pagedTransport map[string]string
+
+func (t pagedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return stubTransport{code: http.StatusOK, body: t[req.URL.Query().Get("page")]}.RoundTrip(req)
+}
+
+func TestImageOrder(t *testing.T) {
+	const img = `<div class="row"><img class="imageFade" src="https://cdni.fancaps.net/file/fancaps-movieimages/%s.jpg"></div>`
+	rt := pagedTransport{
+		"":  fmt.Sprintf(img, "1") + fmt.Sprintf(img, "2") + `<ul class="pagination"><li><a href="?page=2">»</a></li></ul>`,
+		"2": fmt.Sprintf(img, "3"),
+	}
+	c, _ := New(WithTransport(rt), WithPageLimits(1, 0), WithRetries(0, 0))
+	movie := &types.Title{Category: types.CategoryMovie, Name: "Akira", Url: "https://fancaps.net/movies/MovieImages.php?movieid=1", Images: &types.Images{}}
+
+	images, err := c.Images(context.Background(), movie)
+	if err != nil {
+		t.Fatalf("Images() returned unexpected error: %v", err)
+	}
+	var urls []string
+	for _, img := range images {
+		urls = append(urls, img.Url)
+	}
+	want := []string{baseMovieURL + "1.jpg", baseMovieURL + "2.jpg", baseMovieURL + "3.jpg"}
+	if !slices.Equal(urls, want) || !slices.Equal(movie.Images.URLs(), want) {
+		t.Errorf("Images() = %q (title holds %q); want %q", urls, movie.Images.URLs(), want)
+	}
+	if last := images[2]; !strings.HasSuffix(last.PageUrl, "?page=2") || last.ThumbUrl != "https://cdni.fancaps.net/file/fancaps-movieimages/3.jpg" {
+		t.Errorf("last image found on page %q with thumbnail %q; want page 2 and its thumbnail", last.PageUrl, last.ThumbUrl)
+	}
+
+	/* Selected images keep their frame indexes. */
+	movie.SelectImages([]int{2, 3})
+	if got := movie.Images.Frames(); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("Frames() after selection = %v; want [2 3]", got)
+	}
+}
+
+func TestImagesTwice(t *testing.T) {
+	const img = `<div class="row"><img class="imageFade" src="https://cdni.fancaps.net/file/fancaps-animeimages/%s.jpg"></div>`
+	c := newTestClient(t, http.StatusOK, fmt.Sprintf(img, "1")+fmt.Sprintf(img, "2"))
+	ctx := context.Background()
+
+	title := &types.Title{Category: types.CategoryAnime, Name: "Naruto", Url: "https://fancaps.net/anime/showimages.php?1-Naruto", Images: &types.Images{}}
+	episode := &types.Episode{Title: title, Name: "Episode 1", Url: "https://fancaps.net/anime/episodeimages.php?1", Images: &types.Images{}}
+	title.Episodes = []*types.Episode{episode}
+
+	for i := 1; i <= 2; i++ {
+		images, err := c.Images(ctx, episode)
+		if err != nil {
+			t.Fatalf("Images() call %d returned unexpected error: %v", i, err)
+		}
+		if len(images) != 2 || len(episode.Images.List()) != 2 || episode.Total() != 2 || title.Total() != 2 {
+			t.Errorf("Images() call %d = %d images (episode holds %d, totals %d/%d); want 2 images", i, len(images), len(episode.Images.List()), episode.Total(), title.Total())
+		}
+	}
+}
+
+func TestNewRateErrors(t *testing.T) {
+	tests := []struct {
+		name      string         // Name of the test.
+		mode      ratelimit.Mode // Limiter mode.
+		rate      float64        // (Initial) rate.
+		minRate   float64        // Minimum rate.
+		maxRate   float64        // Maximum rate.
+		expectErr bool           // True if an error is expected from the given rates.
+	}{
+		{"fixed", ratelimit.ModeFixed, 2, 0, 0, false},
+		{"adaptive", ratelimit.ModeAdaptive, 1, 0.5, 5, false},
+		{"adaptive rate beyond bounds", ratelimit.ModeAdaptive, 10, 0.5, 5, false}, // Clamped by the limiter.
+
+		{"zero rate", ratelimit.ModeFixed, 0, 0, 0, true},
+		{"negative rate", ratelimit.ModeFixed, -1, 0, 0, true},
+		{"NaN rate", ratelimit.ModeFixed, math.NaN(), 0, 0, true},
+		{"zero min rate", ratelimit.ModeAdaptive, 1, 0, 5, true},
+		{"negative min rate", ratelimit.ModeAdaptive, 1, -1, 5, true},
+		{"min rate above max rate", ratelimit.ModeAdaptive, 1, 5, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(WithTransport(stubTransport{code: http.StatusOK}), WithRateLimit(tt.mode, tt.rate, tt.minRate, tt.maxRate))
+			if tt.expectErr && err == nil {
+				t.Errorf("New() with rates %v/%v/%v expected error but got nil", tt.rate, tt.minRate, tt.maxRate)
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("New() with rates %v/%v/%v returned unexpected error: %v", tt.rate, tt.minRate, tt.maxRate, err)
+			}
+		})
+	}
+}
+
+func TestClientCountersSeparate(t *testing.T) {
+	newMovie := func() *types.Title {
+		movie := &types.Title{Category: types.CategoryMovie, Name: "Movie", Url: "movie-url", Images: &types.Images{}}
+		movie.Images.AddURL("https://cdni.fancaps.net/file/1.jpg")
+		movie.IncrementImageTotal()
+		return movie
+	}
+	first, second := newMovie(), newMovie()
+
+	/* Two clients downloading at once count their own images only. */
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, title := range []*types.Title{first, second} {
+		c := newTestClient(t, http.StatusOK, "image")
+		dir := t.TempDir()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.Download(context.Background(), []*types.Title{title}, DownloadOptions{OutputDir: dir})
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("Download() returned unexpected error: %v", err)
+	}
+
+	for _, title := range []*types.Title{first, second} {
+		if got := types.SumCounts([]*types.Title{title}); got.Downloaded != 1 || got.Total != 1 || got.Bytes != int64(len("image")) {
+			t.Errorf("SumCounts() = %+v; want 1 of 1 image downloaded with %d bytes", got, len("image"))
+		}
+	}
+}
@@ -1,45 +1,77 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"regexp"
+	"slices"
 	"sync"
 
 	"github.com/gocolly/colly"
-	"sheeper.com/fancaps-scraper-go/pkg/cli"
 	"sheeper.com/fancaps-scraper-go/pkg/types"
 )
 
-/* Get episodes from titles `titles`. */
-func GetEpisodes(titles []*types.Title) []*types.Title {
-	var wg sync.WaitGroup
-	flags := cli.Flags()
+/*
+Returns the episodes of the title `title`, which are also set as its episodes.
+Movies have no episodes, so nothing is requested for them.
+Stops requesting episode pages once the context `ctx` is canceled, returning its error.
 
-	if flags.Verbose {
-		fmt.Println("\nVISITING EPISODE URLS:")
+Returns `ErrLayoutChanged`, if no episodes were found on the title page,
+and any error encountered while requesting episode pages.
+*/
+func (c *Client) Episodes(ctx context.Context, title *types.Title) ([]*types.Episode, error) {
+	var scrape func() ([]*types.Episode, error)
+	switch title.Category {
+	case types.CategoryAnime:
+		scrape = func() ([]*types.Episode, error) { return c.scrapeAnimeEpisodes(ctx, title) }
+	case types.CategoryTV:
+		scrape = func() ([]*types.Episode, error) { return c.scrapeTVEpisodes(ctx, title) }
+	case types.CategoryMovie:
+		return nil, nil // Movies do not have episodes and thus do not require episode scraping.
+	default:
+		return nil, fmt.Errorf("unknown category: %s (%s) -> [%s]", title.Name, title.Url, title.Category)
 	}
 
+	var episodes []*types.Episode
+	err := c.withPageSlot(ctx, func() (err error) {
+		episodes, err = scrape()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(episodes) == 0 { // Every title on fancaps.net has at least one episode.
+		err := fmt.Errorf("%w: no episodes found for %s (%s)", ErrLayoutChanged, title.Name, title.Url)
+		c.recordFailure(title.Url, err)
+		return nil, err
+	}
+	title.Episodes = episodes
+
+	return episodes, nil
+}
+
+/*
+Get episodes from titles `titles`. (See `Episodes()`)
+Returns the first error encountered, after all titles were scraped.
+*/
+func (c *Client) ScrapeEpisodes(ctx context.Context, titles []*types.Title) error {
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	c.verbosef("\nVISITING EPISODE URLS:\n")
+
 	/* Get the episodes for each title. */
 	for _, title := range titles {
-		/*
-			From title category, run corresponding episode scraper.
-			Note: Movies do not have episodes and thus do not require episode scraping.
-		*/
 		scrapeEpisodes := func(t *types.Title) {
-			switch t.Category {
-			case types.CategoryAnime:
-				t.Episodes = scrapeAnimeEpisodes(t, flags)
-			case types.CategoryTV:
-				t.Episodes = scrapeTVEpisodes(t, flags)
-			case types.CategoryMovie:
-				// Do nothing
-			default:
-				fmt.Fprintf(os.Stderr, "Unknown Category: %s (%s) -> [%s]\n", title.Name, title.Url, title.Category)
+			if _, err := c.Episodes(ctx, t); err != nil {
+				errOnce.Do(func() { firstErr = err })
 			}
 		}
 
-		if !flags.NoAsync {
+		if c.async {
 			wg.Add(1)
 			go func(title *types.Title) {
 				defer wg.Done()
@@ -49,113 +81,111 @@ func GetEpisodes(titles []*types.Title) []*types.Title {
 			scrapeEpisodes(title)
 		}
 	}
+	wg.Wait()
 
-	if !flags.NoAsync {
-		wg.Wait()
-	}
-
-	/* Debug: Print found titles and episodes. */
-	if flags.Debug {
-		fmt.Println("\n\nFOUND TITLES AND EPISODES:")
-		for _, title := range titles {
-			fmt.Printf("%s [%s] -> %s\n", title.Name, title.Category, title.Url)
-			for _, episode := range title.Episodes {
-				fmt.Printf("\t%s -> %s\n", episode.Name, episode.Url)
-			}
-		}
-		fmt.Printf("\n\n")
-	}
-
-	return titles
+	return firstErr
 }
 
-/* Given a TV series title `title`, return its list of episodes. */
-func scrapeTVEpisodes(title *types.Title, flags cli.CLIFlags) []*types.Episode {
-	var episodes []*types.Episode
+/*
+Given a TV series title `title`, return its list of episodes,
+and any error encountered while requesting its pages.
+*/
+func (c *Client) scrapeTVEpisodes(ctx context.Context, title *types.Title) ([]*types.Episode, error) {
+	var (
+		episodes   []*types.Episode
+		episodesMu sync.Mutex // Prevents overlapping "appends" to `episodes` from concurrent pages.
+	)
 
-	scraperOpts := GetScraperOpts(flags)
-	c := colly.NewCollector(scraperOpts...)
+	col := c.newCollector(ctx)
 
 	/* Extract episode info. (TV-only) */
-	c.OnHTML("h3 > a[href]", func(e *colly.HTMLElement) {
+	col.OnHTML("h3 > a[href]", func(e *colly.HTMLElement) {
 		url := e.Request.AbsoluteURL(e.Attr("href"))
 		episode := &types.Episode{
-			Title:  title,
-			Name:   getEpisodeTitle(e.Text),
-			Url:    url,
-			Images: &types.Images{},
+			Title:    title,
+			Name:     getEpisodeTitle(e.Text),
+			Url:      url,
+			Images:   &types.Images{},
+			Position: elementPosition(e),
 		}
+		episodesMu.Lock()
 		episodes = append(episodes, episode)
+		episodesMu.Unlock()
 	})
 
 	/*
 		If there is a next page,
 		visit it to re-trigger episode info extraction. (TV-only)
 	*/
-	c.OnHTML("ul.pager > li > a[href]", func(e *colly.HTMLElement) {
+	col.OnHTML("ul.pager > li > a[href]", func(e *colly.HTMLElement) {
 		nextPageURL := e.Request.AbsoluteURL(e.Attr("href"))
 		if nextPageURL != "#" && containsNext(e.Text) {
-			c.Visit(nextPageURL)
+			visitNextPage(col, e.Request, nextPageURL)
 		}
 	})
 
-	if flags.Verbose {
-		c.OnRequest(func(req *colly.Request) {
-			fmt.Println("Visiting TV Episode URL:", req.URL.String())
-		})
-	}
-
-	c.Visit(title.Url)
+	col.OnRequest(func(req *colly.Request) {
+		c.verbosef("Visiting TV Episode URL: %s\n", req.URL.String())
+	})
 
-	if !flags.NoAsync {
-		c.Wait()
-	}
+	err := c.visit(ctx, col, title.Url)
+	sortEpisodes(episodes)
 
-	return episodes
+	return episodes, err
 }
 
-/* Given an Anime title `title`, return its list of episodes. */
-func scrapeAnimeEpisodes(title *types.Title, flags cli.CLIFlags) []*types.Episode {
-	var episodes []*types.Episode
+/*
+Given an Anime title `title`, return its list of episodes,
+and any error encountered while requesting its pages.
+*/
+func (c *Client) scrapeAnimeEpisodes(ctx context.Context, title *types.Title) ([]*types.Episode, error) {
+	var (
+		episodes   []*types.Episode
+		episodesMu sync.Mutex // Prevents overlapping "appends" to `episodes` from concurrent pages.
+	)
 
-	scraperOpts := GetScraperOpts(flags)
-	c := colly.NewCollector(scraperOpts...)
+	col := c.newCollector(ctx)
 
 	/* Extract episode info. (Anime-only) */
-	c.OnHTML("a[href] > h3", func(e *colly.HTMLElement) {
+	col.OnHTML("a[href] > h3", func(e *colly.HTMLElement) {
 		href, _ := e.DOM.Parent().Attr("href")
 		url := e.Request.AbsoluteURL(href)
 		episode := &types.Episode{
-			Title:  title,
-			Name:   getEpisodeTitle(e.Text) + " of " + title.Name, // Append title name (required for `getEpisodeByNumber()`)
-			Url:    url,
-			Images: &types.Images{},
+			Title:    title,
+			Name:     getEpisodeTitle(e.Text) + " of " + title.Name, // Append title name (required for `getEpisodeByNumber()`)
+			Url:      url,
+			Images:   &types.Images{},
+			Position: elementPosition(e),
 		}
+		episodesMu.Lock()
 		episodes = append(episodes, episode)
+		episodesMu.Unlock()
 	})
 
 	/*
 		If there is a next page,
 		visit it to re-trigger episode info extraction. (Anime-only)
 	*/
-	c.OnHTML("a[title='Next Page']", func(e *colly.HTMLElement) {
+	col.OnHTML("a[title='Next Page']", func(e *colly.HTMLElement) {
 		nextPageURL := e.Request.AbsoluteURL(e.Attr("href"))
-		c.Visit(nextPageURL)
+		visitNextPage(col, e.Request, nextPageURL)
 	})
 
-	if flags.Verbose {
-		c.OnRequest(func(req *colly.Request) {
-			fmt.Println("Visiting Anime Episode URL:", req.URL.String())
-		})
-	}
+	col.OnRequest(func(req *colly.Request) {
+		c.verbosef("Visiting Anime Episode URL: %s\n", req.URL.String())
+	})
 
-	c.Visit(title.Url)
+	err := c.visit(ctx, col, title.Url)
+	sortEpisodes(episodes)
 
-	if !flags.NoAsync {
-		c.Wait()
-	}
+	return episodes, err
+}
 
-	return episodes
+/* Sorts the episodes `episodes` by their position on the pages of their title. (i.e., in the order of the site) */
+func sortEpisodes(episodes []*types.Episode) {
+	slices.SortStableFunc(episodes, func(a, b *types.Episode) int {
+		return a.Position.Compare(b.Position)
+	})
 }
 
 /* Returns the episode's title. */
@@ -0,0 +1,23 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrEmptyQuery    = errors.New("search query cannot be empty")                // A search query or title name is blank.
+	ErrNotFound      = errors.New("no titles found")                             // A search or title name matched no titles.
+	ErrLayoutChanged = errors.New("unexpected page layout")                      // A page was fetched, but none of the expected elements were found on it.
+	ErrRateLimited   = errors.New("too many consecutive rate-limited responses") // Downloads were aborted, since the server kept rate-limiting them.
+)
+
+/* An HTTP response with an unexpected status code. */
+type ErrBadStatus struct {
+	Code int    // Status code of the response.
+	URL  string // URL of the request.
+}
+
+func (e *ErrBadStatus) Error() string {
+	return fmt.Sprintf("bad status code: %d for URL: %s", e.Code, e.URL)
+}
@@ -1,13 +1,14 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"path"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/gocolly/colly"
-	"sheeper.com/fancaps-scraper-go/pkg/cli"
 	"sheeper.com/fancaps-scraper-go/pkg/types"
 )
 
@@ -25,40 +26,125 @@ var CategoryURLMap = map[types.Category]string{
 	types.CategoryMovie: baseMovieURL,
 }
 
-/* Get images from titles `titles`. */
-func GetImages(titles []*types.Title) {
-	var wg sync.WaitGroup
-	flags := cli.Flags()
+/*
+Returns the images found in the title (Movies) or episode `imgCon` in the order of the site,
+which replace the images of `imgCon`. (i.e., images found by an earlier call are not added twice)
+Stops requesting image pages once the context `ctx` is canceled, returning its error.
+
+Returns `ErrLayoutChanged`, if no images were found on the page of `imgCon`,
+and any error encountered while requesting image pages.
+*/
+func (c *Client) Images(ctx context.Context, imgCon types.ImageContainer) ([]*types.Image, error) {
+	var (
+		images    []*types.Image
+		positions = make(map[*types.Image]types.Position)
+		imagesMu  sync.Mutex // Prevents overlapping "appends" to `images`.
+	)
+	found := func(_ types.ImageContainer, img *types.Image, pos types.Position, _ int) {
+		imagesMu.Lock()
+		images = append(images, img)
+		positions[img] = pos
+		imagesMu.Unlock()
+	}
+
+	var scrape func() error
+	switch ic := imgCon.(type) {
+	case *types.Title:
+		if ic.Category != types.CategoryMovie {
+			return nil, fmt.Errorf("title %s has episodes: scrape the images of its episodes instead", ic.Name)
+		}
+		scrape = func() error { return c.scrapeTitleImages(ctx, ic, found) }
+	case *types.Episode:
+		scrape = func() error { return c.scrapeEpisodeImages(ctx, ic, ic.Title, found) }
+	default:
+		return nil, fmt.Errorf("unsupported image container: %T", imgCon)
+	}
+	imgCon.SelectImages(nil) // Removes the images found before, along with their totals.
+
+	if err := c.withPageSlot(ctx, scrape); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(images, func(a, b *types.Image) int {
+		return positions[a].Compare(positions[b])
+	})
+
+	return images, nil
+}
+
+/*
+Get images from titles `titles`. (See `Images()`)
+Returns the first error encountered, after all titles were scraped.
+*/
+func (c *Client) ScrapeImages(ctx context.Context, titles []*types.Title) error {
+	return c.scrapeImages(ctx, titles, nil, nil)
+}
+
+/*
+Collects the images of titles `titles` and their episodes, calling `found` (if non-nil)
+for each image, as soon as the image is found.
+Titles and episodes are no longer marked as scraping once all their pages were scraped,
+after which `scraped` (if non-nil) is called with them. (See `types.Images.SetScraping()`)
+Stops requesting image pages once the context `ctx` is canceled.
+Returns the first error encountered, after all titles were scraped.
+*/
+func (c *Client) scrapeImages(ctx context.Context, titles []*types.Title, found imageFoundFunc, scraped func(imgCon types.ImageContainer)) error {
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	setErr := func(err error) {
+		if err != nil {
+			errOnce.Do(func() { firstErr = err })
+		}
+	}
+	setScraped := func(imgCon types.ImageContainer, images *types.Images) {
+		images.SetScraping(false)
+		if scraped != nil {
+			scraped(imgCon)
+		}
+	}
 
 	/* For each title... */
 	for _, title := range titles {
 		/* Handle movies seperately, since they have no episodes. */
 		if title.Category == types.CategoryMovie {
-			if !flags.NoAsync {
+			scrapeTitleImgs := func(t *types.Title) {
+				setErr(c.withPageSlot(ctx, func() error { return c.scrapeTitleImages(ctx, t, found) }))
+				setScraped(t, t.Images)
+			}
+
+			if c.async {
 				wg.Add(1)
 				go func(t *types.Title) {
 					defer wg.Done()
-					scrapeTitleImages(title, flags)
+					scrapeTitleImgs(t)
 				}(title)
 			} else {
-				scrapeTitleImages(title, flags)
+				scrapeTitleImgs(title)
 			}
 			continue // Go to the next title.
 		}
 
-		/* For each episode... */
-		for _, episode := range title.Episodes {
-			/* Get the episode's images. */
-			scrapeEpisodeImgs := func(title *types.Title, episode *types.Episode) {
-				switch title.Category {
-				case types.CategoryAnime, types.CategoryTV:
-					scrapeEpisodeImages(episode, title, flags)
-				default:
-					fmt.Fprintf(os.Stderr, "Unknown Category: %s (%s) -> [%s]\n", title.Name, title.Url, title.Category)
-				}
+		/* Get the episode's images. */
+		var titleWg sync.WaitGroup // Synchronizes the episode scrapers of the title.
+		scrapeEpisodeImgs := func(title *types.Title, episode *types.Episode) {
+			defer titleWg.Done()
+
+			switch title.Category {
+			case types.CategoryAnime, types.CategoryTV:
+				setErr(c.withPageSlot(ctx, func() error { return c.scrapeEpisodeImages(ctx, episode, title, found) }))
+			default:
+				setErr(fmt.Errorf("unknown category: %s (%s) -> [%s]", title.Name, title.Url, title.Category))
 			}
+			setScraped(episode, episode.Images)
+		}
 
-			if !flags.NoAsync {
+		/* For each episode... */
+		titleWg.Add(len(title.Episodes))
+		for _, episode := range title.Episodes {
+			if c.async {
 				wg.Add(1)
 				go func(t *types.Title, e *types.Episode) {
 					defer wg.Done()
@@ -68,27 +154,39 @@ func GetImages(titles []*types.Title) {
 				scrapeEpisodeImgs(title, episode)
 			}
 		}
-	}
 
-	if !flags.NoAsync {
-		wg.Wait()
+		/* The title is scraped once all of its episodes are. */
+		wg.Add(1)
+		go func(t *types.Title) {
+			defer wg.Done()
+			titleWg.Wait()
+			setScraped(t, t.Images)
+		}(title)
 	}
 
-	/* Debug: Print amount of found images per title/episode. */
-	if flags.Debug {
-		fmt.Println("\n\nFOUND IMAGES:")
-		for _, title := range titles {
-			fmt.Printf("%s [%s] -> %d images\n", title.Name, title.Category, title.Images.Total())
+	wg.Wait()
 
-			if title.Category == types.CategoryMovie {
-				continue // Don't show movie episodes. They don't have any.
-			}
+	return firstErr
+}
 
-			for _, episode := range title.Episodes {
-				fmt.Printf("\t%s -> %d images\n", episode.Name, episode.Images.Total())
-			}
-		}
-		fmt.Printf("\n\n")
+/*
+Called with the title or episode `imgCon` holding a newly found image `img`,
+its position `pos` on the pages of `imgCon` and its current frame index `frame`. (See `types.Images.Insert()`)
+*/
+type imageFoundFunc func(imgCon types.ImageContainer, img *types.Image, pos types.Position, frame int)
+
+/*
+Returns the pending image of the image element `e` (a thumbnail) of a title of category `category`,
+pointing to its full-size image.
+*/
+func newImage(e *colly.HTMLElement, category types.Category) *types.Image {
+	src := e.Attr("src")
+
+	return &types.Image{
+		PageUrl:  e.Request.URL.String(),
+		ThumbUrl: e.Request.AbsoluteURL(src),
+		Url:      CategoryURLMap[category] + path.Base(src),
+		Status:   types.ImagePending,
 	}
 }
 
@@ -100,47 +198,55 @@ Intended to be used only alongside titles with *NO* episodes. (e.g., Movies)
 `title` will have its URL list and image count updated directly from the Title struct.
 See `GetEpisodeImages()` for more details on how to handle image collection for titles
 with episodes.
+
+Returns `ErrLayoutChanged`, if no images were found, and any error encountered while requesting its pages.
 */
-func scrapeTitleImages(title *types.Title, flags cli.CLIFlags) {
-	scraperOpts := GetScraperOpts(flags)
-	c := colly.NewCollector(scraperOpts...)
+func (c *Client) scrapeTitleImages(ctx context.Context, title *types.Title, found imageFoundFunc) error {
+	var imgCount atomic.Int32 // Amount of images found. (Counted by concurrent pages)
+
+	col := c.newCollector(ctx)
 
 	/* Extract title image. */
-	c.OnHTML("div.row img.imageFade", func(e *colly.HTMLElement) {
+	col.OnHTML("div.row img.imageFade", func(e *colly.HTMLElement) {
 		/* Skip "Top Images". (They will be downloaded anyway.) */
 		if e.DOM.ParentsFiltered("div.topImages").Length() > 0 {
 			return
 		}
 
-		/* Get image URL. */
-		src := e.Attr("src")
-		file := path.Base(src)
-		imgURL := CategoryURLMap[title.Category] + file
-
-		title.Images.AddURL(imgURL)
+		/* Get image. */
+		img := newImage(e, title.Category)
+		pos := elementPosition(e)
+		frame := title.Images.Insert(img, pos)
 		title.IncrementImageTotal()
-
-		if flags.Verbose {
-			fmt.Printf("%s [%s] image found! (%s)\n", title.Name, title.Category, imgURL)
+		imgCount.Add(1)
+		if found != nil {
+			found(title, img, pos, frame)
 		}
+
+		c.verbosef("%s [%s] image found! (%s)\n", title.Name, title.Category, img.Url)
 	})
 
 	/*
 		If there is a next page,
 		visit it to re-trigger episode image extraction. (Anime-only)
 	*/
-	c.OnHTML("ul.pagination > li > a[href]", func(e *colly.HTMLElement) {
+	col.OnHTML("ul.pagination > li > a[href]", func(e *colly.HTMLElement) {
 		nextPageURL := e.Request.AbsoluteURL(e.Attr("href"))
 		if e.Text == "»" && nextPageURL != "#" {
-			c.Visit(nextPageURL)
+			visitNextPage(col, e.Request, nextPageURL)
 		}
 	})
 
-	c.Visit(title.Url)
-
-	if !flags.NoAsync {
-		c.Wait()
+	if err := c.visit(ctx, col, title.Url); err != nil {
+		return err
 	}
+	if imgCount.Load() == 0 { // Every title and episode on fancaps.net has at least one image.
+		err := fmt.Errorf("%w: no images found for %s (%s)", ErrLayoutChanged, title.Name, title.Url)
+		c.recordFailure(title.Url, err)
+		return err
+	}
+
+	return nil
 }
 
 /*
@@ -152,45 +258,53 @@ Intended to be used only alongside titles with episodes. (e.g., Anime, TV Series
 `title` will only have its image count updated, its URL list will
 be left alone. This is intentional, as only Movie titles will directly store all
 of their URLs in the Title struct. See `GetTitleImages()` for more details.
+
+Returns `ErrLayoutChanged`, if no images were found, and any error encountered while requesting its pages.
 */
-func scrapeEpisodeImages(episode *types.Episode, title *types.Title, flags cli.CLIFlags) {
-	scraperOpts := GetScraperOpts(flags)
-	c := colly.NewCollector(scraperOpts...)
+func (c *Client) scrapeEpisodeImages(ctx context.Context, episode *types.Episode, title *types.Title, found imageFoundFunc) error {
+	var imgCount atomic.Int32 // Amount of images found. (Counted by concurrent pages)
+
+	col := c.newCollector(ctx)
 
 	/* Extract episode image. */
-	c.OnHTML("div.row img.imageFade", func(e *colly.HTMLElement) {
+	col.OnHTML("div.row img.imageFade", func(e *colly.HTMLElement) {
 		/* Skip "Top Images". (They will be downloaded anyway.) */
 		if e.DOM.ParentsFiltered("div.topImages").Length() > 0 {
 			return
 		}
 
-		/* Get image URL. */
-		src := e.Attr("src")
-		file := path.Base(src)
-		imgURL := CategoryURLMap[title.Category] + file
-
-		episode.Images.AddURL(imgURL)
+		/* Get image. */
+		img := newImage(e, title.Category)
+		pos := elementPosition(e)
+		frame := episode.Images.Insert(img, pos)
 		episode.IncrementImageTotal()
-
-		if flags.Verbose {
-			fmt.Printf("%s - %s [%s] image found! (%s)\n", title.Name, episode.Name, title.Category, imgURL)
+		imgCount.Add(1)
+		if found != nil {
+			found(episode, img, pos, frame)
 		}
+
+		c.verbosef("%s - %s [%s] image found! (%s)\n", title.Name, episode.Name, title.Category, img.Url)
 	})
 
 	/*
 		If there is a next page,
 		visit it to re-trigger episode image extraction. (Anime-only)
 	*/
-	c.OnHTML("ul.pagination > li > a[href]", func(e *colly.HTMLElement) {
+	col.OnHTML("ul.pagination > li > a[href]", func(e *colly.HTMLElement) {
 		nextPageURL := e.Request.AbsoluteURL(e.Attr("href"))
 		if e.Text == "»" && nextPageURL != "#" {
-			c.Visit(nextPageURL)
+			visitNextPage(col, e.Request, nextPageURL)
 		}
 	})
 
-	c.Visit(episode.Url)
-
-	if !flags.NoAsync {
-		c.Wait()
+	if err := c.visit(ctx, col, episode.Url); err != nil {
+		return err
+	}
+	if imgCount.Load() == 0 { // Every title and episode on fancaps.net has at least one image.
+		err := fmt.Errorf("%w: no images found for %s (%s)", ErrLayoutChanged, episode.Name, episode.Url)
+		c.recordFailure(episode.Url, err)
+		return err
 	}
+
+	return nil
 }
@@ -0,0 +1,44 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+
+	"sheeper.com/fancaps-scraper-go/pkg/format"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/*
+Returns the titles read from the input file `filename`, and whether they were read
+along with their episodes and image URLs (i.e., whether they can be downloaded without scraping).
+
+Structured files (JSON, CSV, YAML), as produced by a dry run, are parsed as is.
+Any other file is read as a plain list of title URLs or exact title names (one per line),
+which are resolved like `ResolveTitles()`, searching only categories in `categories`.
+
+Returns an error, if the input file cannot be read or holds no titles.
+*/
+func (c *Client) LoadInput(ctx context.Context, filename string, categories []types.Category) ([]*types.Title, bool, error) {
+	if format.IsStructured(filename) {
+		titles, err := format.ParseFile(filename)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(titles) == 0 {
+			return nil, false, fmt.Errorf("%w in %s", ErrNotFound, filename)
+		}
+
+		return titles, true, nil
+	}
+
+	titleArgs, err := format.ReadLines(filename)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(titleArgs) == 0 {
+		return nil, false, fmt.Errorf("%w in %s", ErrNotFound, filename)
+	}
+
+	titles, err := c.ResolveTitles(ctx, titleArgs, categories)
+	return titles, false, err
+}
@@ -0,0 +1,173 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"sheeper.com/fancaps-scraper-go/pkg/fsutil"
+)
+
+var errStalePartial = errors.New("partial image cannot be resumed") // The server rejected resuming a partial image.
+
+/* What is needed to resume a partial image, stored in its sidecar file. */
+type partialMeta struct {
+	URL          string `json:"url"`                     // URL of the image.
+	ETag         string `json:"etag,omitempty"`          // ETag of the image, when its download started.
+	LastModified string `json:"last_modified,omitempty"` // Last-Modified date of the image, when its download started.
+}
+
+/*
+Returns the If-Range validator of the partial image described by `m`: its ETag, if strong,
+or else its Last-Modified date. Returns an empty string, if there is none.
+*/
+func (m partialMeta) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag // Weak ETags cannot be used with If-Range.
+	}
+
+	return m.LastModified
+}
+
+/* An image being downloaded to a partial file, which may be resumed with a Range request. */
+type partialImage struct {
+	path     string      // Path of the partial file.
+	metaPath string      // Path of the sidecar file.
+	meta     partialMeta // Contents of the sidecar file.
+	offset   int64       // Bytes already downloaded, if the partial file can be resumed. 0, otherwise.
+}
+
+/*
+Returns the partial image of the image at URL `url` being downloaded to the path `imgPath`.
+The partial image can be resumed, if a previous download left a partial file
+along with a sidecar file holding a validator for the same URL.
+*/
+func loadPartial(imgPath, url string) *partialImage {
+	path, metaPath := fsutil.PartialImagePaths(imgPath)
+	p := &partialImage{path: path, metaPath: metaPath}
+
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return p
+	}
+	var meta partialMeta
+	if json.Unmarshal(data, &meta) != nil || meta.URL != url || meta.validator() == "" {
+		return p
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return p
+	}
+	p.meta = meta
+	p.offset = info.Size()
+
+	return p
+}
+
+/*
+Sets the headers of the request `req` needed to resume the partial image `p`, if it can be resumed.
+The server answers with the rest of the image if it is unchanged, or the full image otherwise.
+*/
+func (p *partialImage) setRangeHeaders(req *http.Request) {
+	if p.offset == 0 {
+		return
+	}
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", p.offset))
+	req.Header.Set("If-Range", p.meta.validator())
+}
+
+/*
+Opens the partial file of the partial image `p` of the image at URL `url` for writing the body
+of the response `res`: appending to it for a partial response (206), or from scratch for a full response.
+
+For full responses, a new sidecar file is written if the server supports Range requests,
+so that the download can be resumed later. Otherwise, any previous sidecar file is removed.
+Returns errStalePartial, if the partial response doesn't resume where the partial file left off.
+*/
+func (p *partialImage) open(res *http.Response, url string) (*os.File, error) {
+	if res.StatusCode == http.StatusPartialContent {
+		if start, ok := contentRangeStart(res.Header.Get("Content-Range")); !ok || start != p.offset || p.offset == 0 {
+			p.discard()
+			return nil, fmt.Errorf("%w: unexpected Content-Range %q", errStalePartial, res.Header.Get("Content-Range"))
+		}
+
+		return os.OpenFile(p.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	}
+
+	/* Start over. */
+	p.offset = 0
+	p.meta = partialMeta{}
+	if err := os.Remove(p.metaPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(p.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := partialMeta{
+		URL:          url,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
+	if res.Header.Get("Accept-Ranges") == "bytes" && meta.validator() != "" {
+		data, err := json.Marshal(meta)
+		if err == nil {
+			err = os.WriteFile(p.metaPath, data, 0o644)
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to write sidecar file: %w", err)
+		}
+		p.meta = meta
+	}
+
+	return f, nil
+}
+
+/* Moves the completely downloaded partial image `p` to the path `imgPath`, and removes its sidecar file. */
+func (p *partialImage) complete(imgPath string) error {
+	if err := os.Rename(p.path, imgPath); err != nil {
+		return err
+	}
+	os.Remove(p.metaPath)
+
+	return nil
+}
+
+/* Removes the partial file and sidecar file of the partial image `p`, so its download starts over. */
+func (p *partialImage) discard() {
+	os.Remove(p.path)
+	os.Remove(p.metaPath)
+	p.offset = 0
+	p.meta = partialMeta{}
+}
+
+/*
+Returns the first byte position of the Content-Range header value `header` ("bytes start-end/size"),
+and whether it could be parsed.
+*/
+func contentRangeStart(header string) (int64, bool) {
+	rng, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
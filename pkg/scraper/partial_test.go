@@ -0,0 +1,114 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sheeper.com/fancaps-scraper-go/pkg/fsutil"
+	"sheeper.com/fancaps-scraper-go/pkg/logf"
+	"sheeper.com/fancaps-scraper-go/pkg/ratelimit"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/* Returns a downloader for tests, without rate limits. */
+func newTestDownloader() *downloader {
+	return &downloader{
+		breaker: newCircuitBreaker(rateLimitThreshold, func() {}),
+		limiter: ratelimit.New(ratelimit.ModeFixed, 1000, 1000, 1000, 1),
+		client:  &http.Client{},
+		log:     func(logf.LogSeverity, string, ...any) {},
+	}
+}
+
+/* Returns a server of the image contents `content` with the ETag `etag`, recording the Range headers of requests. */
+func newImageServer(t *testing.T, content []byte, etag string, ranges *[]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "img.jpg", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestFetchImageResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	var ranges []string
+	srv := newImageServer(t, content, `"v1"`, &ranges)
+	url := srv.URL + "/img.jpg"
+
+	/* Leave a resumable partial image behind. */
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "img.jpg")
+	partialPath, metaPath := fsutil.PartialImagePaths(imgPath)
+	os.WriteFile(partialPath, content[:400], 0o644)
+	os.WriteFile(metaPath, []byte(`{"url":"`+url+`","etag":"\"v1\""}`), 0o644)
+
+	if _, _, err := newTestDownloader().fetchImage(context.Background(), &types.Image{Url: url}, imgPath); err != nil {
+		t.Fatalf("fetchImage() returned unexpected error: %v", err)
+	}
+
+	if len(ranges) != 1 || ranges[0] != "bytes=400-" {
+		t.Errorf("Range headers = %q; want [\"bytes=400-\"]", ranges)
+	}
+	if got, _ := os.ReadFile(imgPath); !bytes.Equal(got, content) {
+		t.Errorf("resumed image has %d bytes; want the original %d bytes", len(got), len(content))
+	}
+	for _, p := range []string{partialPath, metaPath} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after a complete download", p)
+		}
+	}
+}
+
+func TestFetchImageChanged(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 100)
+	var ranges []string
+	srv := newImageServer(t, content, `"v2"`, &ranges)
+	url := srv.URL + "/img.jpg"
+
+	/* The partial image belongs to an older version of the image. */
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "img.jpg")
+	partialPath, metaPath := fsutil.PartialImagePaths(imgPath)
+	os.WriteFile(partialPath, bytes.Repeat([]byte("x"), 400), 0o644)
+	os.WriteFile(metaPath, []byte(`{"url":"`+url+`","etag":"\"v1\""}`), 0o644)
+
+	if _, _, err := newTestDownloader().fetchImage(context.Background(), &types.Image{Url: url}, imgPath); err != nil {
+		t.Fatalf("fetchImage() returned unexpected error: %v", err)
+	}
+
+	if got, _ := os.ReadFile(imgPath); !bytes.Equal(got, content) {
+		t.Errorf("image has %d bytes (%q...); want the full new image", len(got), got[:min(len(got), 10)])
+	}
+}
+
+func TestRemoveStalePartials(t *testing.T) {
+	dir := t.TempDir()
+	resumable, resumableMeta := fsutil.PartialImagePaths(filepath.Join(dir, "1.jpg"))
+	stale, _ := fsutil.PartialImagePaths(filepath.Join(dir, "2.jpg"))
+	_, orphanMeta := fsutil.PartialImagePaths(filepath.Join(dir, "3.jpg"))
+	for _, p := range []string{resumable, resumableMeta, stale, orphanMeta} {
+		os.WriteFile(p, nil, 0o644)
+	}
+
+	removed, err := fsutil.RemoveStalePartials(dir)
+	if err != nil {
+		t.Fatalf("RemoveStalePartials() returned unexpected error: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("RemoveStalePartials() removed %q; want the stale partial and orphaned sidecar", removed)
+	}
+	for _, p := range []string{resumable, resumableMeta} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("resumable file %s was removed", p)
+		}
+	}
+}
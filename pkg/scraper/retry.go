@@ -0,0 +1,159 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	baseBackoff        = 2 * time.Second // Maximum delay before the first retry. Doubles with each retry, up to the maximum backoff.
+	rateLimitThreshold = 5               // Consecutive rate-limit responses tolerated (beyond one per parallel download) before aborting all downloads.
+)
+
+var errIncompleteImage = errors.New("incomplete image contents") // The image was cut short by the server.
+
+/* Policy for retrying transient download failures. */
+type retryPolicy struct {
+	retries    int           // Maximum amount of retries after the first attempt.
+	maxBackoff time.Duration // Maximum delay between two attempts, unless the server asks for more. (See `backoff()`)
+}
+
+/*
+Returns the delay before retry number `attempt` (starting from 1) of the policy `p`.
+
+The delay is picked at random between 0 and an exponentially growing cap ("full jitter"),
+which never exceeds the maximum backoff of `p`.
+If the server asked to wait `retryAfter` (non-zero), the delay is at least `retryAfter`.
+*/
+func (p retryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	limit := p.maxBackoff
+	if shift := attempt - 1; shift < 32 {
+		limit = min(baseBackoff<<shift, p.maxBackoff)
+	}
+
+	var delay time.Duration
+	if limit > 0 {
+		delay = time.Duration(rand.Int63n(int64(limit) + 1))
+	}
+
+	return max(delay, retryAfter)
+}
+
+/*
+Returns the delay requested by the value of a Retry-After header `header`, relative to `now`.
+Both delay-seconds and HTTP-date values are supported. Returns 0, if `header` is empty or invalid.
+*/
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
+
+/* Returns true, if the HTTP status code `code` indicates that the server is rate-limiting requests. */
+func isRateLimitStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusForbidden
+}
+
+/* Returns true, if a request answered with the HTTP status code `code` is worth retrying. */
+func isTransientStatus(code int) bool {
+	return isRateLimitStatus(code) || code == http.StatusRequestTimeout || code >= 500
+}
+
+/* Returns true, if a request that failed with error `err` is worth retrying. */
+func isTransientError(err error) bool {
+	if errors.Is(err, errIncompleteImage) ||
+		errors.Is(err, errStalePartial) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+/*
+Sleeps for `d` time.
+Returns the context's error, if the context `ctx` was canceled before the sleep was over.
+*/
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Trips after `threshold` consecutive rate-limit responses, at which point
+continuing to send requests would only prolong the rate limit.
+*/
+type circuitBreaker struct {
+	threshold   int        // Consecutive rate-limit responses needed to trip.
+	consecutive int        // Current amount of consecutive rate-limit responses.
+	tripped     bool       // If true, the breaker has tripped.
+	onTrip      func()     // Called once, when the breaker trips.
+	mu          sync.Mutex // Prevents bad writes from concurrent downloads.
+}
+
+/* Returns a new circuit breaker tripping after `threshold` rate-limit responses, calling `onTrip` when it does. */
+func newCircuitBreaker(threshold int, onTrip func()) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		onTrip:    onTrip,
+	}
+}
+
+/* Records a rate-limit response, and returns whether the breaker `cb` has tripped. */
+func (cb *circuitBreaker) RecordRateLimit() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutive++
+	if !cb.tripped && cb.consecutive >= cb.threshold {
+		cb.tripped = true
+		cb.onTrip()
+	}
+
+	return cb.tripped
+}
+
+/* Records a response which was not rate-limited, resetting the breaker `cb`, unless it has tripped. */
+func (cb *circuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutive = 0
+}
+
+/* Returns true, if the breaker `cb` has tripped. */
+func (cb *circuitBreaker) Tripped() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.tripped
+}
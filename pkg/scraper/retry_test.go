@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header   string        // Retry-After header value.
+		expected time.Duration // Expected delay.
+	}{
+		{"", 0},
+		{"0", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-30 * time.Second).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := parseRetryAfter(tt.header, now); got != tt.expected {
+				t.Errorf("parseRetryAfter(%q) = %v; want %v", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := retryPolicy{retries: 10, maxBackoff: 10 * time.Second}
+
+	for attempt := 1; attempt <= 100; attempt++ {
+		limit := min(baseBackoff<<min(attempt-1, 31), p.maxBackoff)
+		if got := p.backoff(attempt, 0); got < 0 || got > limit {
+			t.Errorf("backoff(%d, 0) = %v; want within [0, %v]", attempt, got, limit)
+		}
+	}
+
+	/* Retry-After takes precedence, even over the maximum backoff. */
+	if got := p.backoff(1, time.Minute); got != time.Minute {
+		t.Errorf("backoff(1, 1m) = %v; want 1m", got)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{fmt.Errorf("copy: %w", io.ErrUnexpectedEOF), true},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{fmt.Errorf("%w: got 1 of 2 bytes", errIncompleteImage), true},
+		{errors.New("permission denied"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isTransientError(tt.err); got != tt.expected {
+			t.Errorf("isTransientError(%v) = %v; want %v", tt.err, got, tt.expected)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	trips := 0
+	cb := newCircuitBreaker(3, func() { trips++ })
+
+	cb.RecordRateLimit()
+	cb.RecordRateLimit()
+	cb.RecordSuccess() // Resets the consecutive count.
+	cb.RecordRateLimit()
+	cb.RecordRateLimit()
+	if cb.Tripped() {
+		t.Fatalf("breaker tripped before reaching the threshold")
+	}
+
+	if !cb.RecordRateLimit() || !cb.Tripped() {
+		t.Fatalf("breaker did not trip after reaching the threshold")
+	}
+	cb.RecordRateLimit()
+	if trips != 1 {
+		t.Errorf("onTrip called %d times; want 1", trips)
+	}
+}
@@ -1,187 +1,615 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
 	"os"
-	"path"
 	"path/filepath"
 	"sync"
 	"time"
 
-	"sheeper.com/fancaps-scraper-go/pkg/cli"
 	"sheeper.com/fancaps-scraper-go/pkg/fsutil"
+	"sheeper.com/fancaps-scraper-go/pkg/job"
 	"sheeper.com/fancaps-scraper-go/pkg/logf"
+	"sheeper.com/fancaps-scraper-go/pkg/progress"
+	"sheeper.com/fancaps-scraper-go/pkg/ratelimit"
 	"sheeper.com/fancaps-scraper-go/pkg/types"
-	"sheeper.com/fancaps-scraper-go/pkg/ui/progressbar"
 )
 
-/* Download images from titles `titles`. */
-func DownloadImages(titles []*types.Title) {
-	var wg sync.WaitGroup
-	flags := cli.Flags()
-	sema := make(chan struct{}, flags.ParallelDownloads)
+/* Options of a download. (See `Download()`) */
+type DownloadOptions struct {
+	OutputDir  string            // Directory to download images to. Ignored, if a manifest is given.
+	Manifest   *job.Manifest     // Job manifest recording the status of every image. If nil, a new one is created in the output directory, unless it holds an unfinished job.
+	Stream     bool              // If true, the images of titles are scraped while they are downloaded.
+	Observer   progress.Observer // Receives the events of the downloads, one at a time. (Optional)
+	FrameNames bool              // If true, image filenames are prefixed with their frame index. (See `fsutil.ImageFilename()`) Ignored, if a manifest is given. (See `job.Manifest.FrameNames`)
+}
+
+/*
+Download images from titles `titles` to the output directory of the job manifest of `opts`.
+The status of every processed image is recorded in the manifest, which is saved as downloads progress,
+so that an interrupted job can be resumed later. (See `job.Manifest.PendingTitles()`)
+
+If streaming is enabled, the images of titles and their episodes are scraped first, and downloaded as soon
+as they are found, instead of waiting for all pages to be scraped. Found images are added to the manifest,
+and the totals of titles and episodes grow as pages are scraped. Scraping stops once the downloads are aborted.
+Otherwise, the images already held by titles and episodes are downloaded.
+Since frame indexes may still shift while pages are scraped, streaming cannot be combined with frame names.
+
+Image requests of all downloads share the rate limiter of the client `c`, which adapts to the server's responses
+in adaptive mode, as well as its bandwidth limit (if any). Transient failures are retried with exponential backoff.
+Once all images were processed, images which failed to download are retried once more after the retry pass delay
+of `c`, unless disabled. (See `WithRetryPass()`)
+If the server keeps rate-limiting requests, all downloads are aborted and `ErrRateLimited` is returned.
+
+Once the context `ctx` is canceled, no new downloads are started and in-flight downloads are aborted,
+keeping their partially written images to be resumed later.
+The observer of `opts` (if any) is notified of the progress of the downloads. (See `progress.Event`)
+
+Returns an error, if the output directory cannot be created, or if streaming is combined with frame names.
+Without a manifest in `opts`, an error wrapping `job.ErrUnfinished` is returned before anything is downloaded,
+if the output directory holds a manifest with images left to download, so that the job can still be resumed.
+Failed downloads are recorded in the manifest and logged, but are not returned.
+Likewise, pages which could not be scraped while streaming are recorded as failed pages. (See `FailedPages()`)
+*/
+func (c *Client) Download(ctx context.Context, titles []*types.Title, opts DownloadOptions) error {
+	manifest := opts.Manifest
+	if manifest == nil {
+		if n := job.Unfinished(opts.OutputDir); n > 0 {
+			return fmt.Errorf("%w: %s has %d pending image(s)", job.ErrUnfinished, filepath.Join(opts.OutputDir, job.ManifestName), n)
+		}
+		manifest = job.New(opts.OutputDir, titles)
+		manifest.FrameNames = opts.FrameNames
+	}
+
+	/* Frames may still shift while earlier pages are scraped, so they cannot name files yet. */
+	if opts.Stream && manifest.FrameNames {
+		return errors.New("frame names cannot be used while streaming, since frames are only known once all pages are scraped")
+	}
+	obs := progress.NewSerial(opts.Observer)
+	done := newDoneTracker(obs, c.retryPassDelay > 0) // Images which failed may still be retried.
+
+	if !opts.Stream {
+		return c.downloadImages(ctx, titles, manifest, obs, done, func(ctx context.Context, send func(imageJob) bool) {
+			for _, title := range titles {
+				/* Handle movies seperately, since they have no episodes. */
+				if title.Category == types.CategoryMovie {
+					for _, img := range title.Images.List() {
+						if !send(imageJob{imgCon: title, img: img, frame: img.Frame}) {
+							return
+						}
+					}
+					continue // Go to next title.
+				}
+
+				for _, episode := range title.Episodes {
+					for _, img := range episode.Images.List() {
+						if !send(imageJob{imgCon: episode, img: img, frame: img.Frame}) {
+							return
+						}
+					}
+				}
+			}
+		})
+	}
 
-	downloadImg := func(imgDir string, imgCon types.ImageContainer, url string) {
-		if exists, imgPath := fsutil.ImageExists(imgDir, url); exists {
-			logf.LogErrorf(logf.LOG_WARNING, "Skipping existing file: %s", imgPath)
-			progressbar.UpdateProgressDisplay(titles, imgCon.IncrementSkipped)
+	/* Titles and episodes are not done until all of their pages are scraped. */
+	for _, title := range titles {
+		title.Images.SetScraping(true)
+		for _, episode := range title.Episodes {
+			episode.Images.SetScraping(true)
+		}
+	}
+
+	return c.downloadImages(ctx, titles, manifest, obs, done, func(ctx context.Context, send func(imageJob) bool) {
+		found := func(imgCon types.ImageContainer, img *types.Image, _ types.Position, frame int) {
+			manifest.AddImage(imgCon, img.Url, frame)
+			obs.Notify(progress.ImageFound{Container: imgCon, URL: img.Url})
+			send(imageJob{imgCon: imgCon, img: img, frame: frame})
+		}
+		c.scrapeImages(ctx, titles, found, done.check) // Failures are recorded as failed pages.
+	})
+}
+
+/* An image to download. */
+type imageJob struct {
+	imgCon types.ImageContainer // Title (Movies) or episode holding the image.
+	img    *types.Image         // The image, updated as it is downloaded.
+	frame  int                  // Frame index of the image when it was produced. (`img.Frame` may still shift while scraping)
+}
+
+/* An image which failed to download, to be retried. */
+type failedJob struct {
+	dir string   // Directory of the title or episode holding the image.
+	job imageJob // The failed image.
+}
+
+/*
+Downloads the images of titles `titles` produced by `produce` to the output directory of the job manifest `manifest`,
+using a bounded pool of parallel downloads, and notifies the observer `obs` of every processed image.
+If the manifest names images by frame, their filenames are prefixed with their frame index.
+Titles and episodes are checked for completion through the tracker `done`, as their images are processed.
+`produce` is run concurrently, and hands each image to the pool through `send`, which blocks until a download
+slot is free. `send` returns false once the downloads are aborted, after which `produce` should return.
+See `Download()` for details on how images are downloaded.
+*/
+func (c *Client) downloadImages(ctx context.Context, titles []*types.Title, manifest *job.Manifest, obs progress.Observer, done *doneTracker, produce func(ctx context.Context, send func(imageJob) bool)) error {
+	var (
+		wg       sync.WaitGroup
+		failed   []failedJob // Images which failed to download, for the retry pass.
+		failedMu sync.Mutex  // Prevents overlapping "appends" to `failed`.
+	)
+	sema := make(chan struct{}, c.parallelDownloads)
+
+	/* Abort all downloads once the circuit breaker trips. */
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	d := &downloader{
+		policy: c.policy,
+		/* Tolerate a full wave of rate-limited parallel downloads before counting towards the threshold. */
+		breaker:   newCircuitBreaker(c.parallelDownloads+rateLimitThreshold, func() { cancel(ErrRateLimited) }),
+		limiter:   c.limiter,
+		bandwidth: c.bandwidthLimiter,
+		client:    &http.Client{Transport: c.transport},
+		log:       c.log,
+	}
+
+	downloadImg := func(imgDir string, j imageJob) {
+		if ctx.Err() != nil {
+			return // Interrupted. Leave the image unprocessed.
+		}
+
+		imgCon, img, url := j.imgCon, j.img, j.img.Url
+		imgFilename := fsutil.ImageFilename(url, 0)
+		if manifest.FrameNames {
+			imgFilename = fsutil.ImageFilename(url, j.frame)
+		}
+
+		exists, imgPath := fsutil.ImageExists(imgDir, imgFilename)
+		img.Path = imgPath
+		if exists {
+			c.log(logf.LOG_WARNING, "Skipping existing file: %s", imgPath)
+			img.Status = types.ImageSkipped
+			if info, err := os.Stat(imgPath); err == nil {
+				img.Size = info.Size()
+			}
+			c.updateManifest(manifest, url, job.StatusSkipped, nil)
+			imgCon.IncrementSkipped()
+			obs.Notify(progress.ImageSkipped{Container: imgCon, URL: url, Path: imgPath})
+			done.check(imgCon)
 			return
 		}
 
-		/* Pre-delay. */
-		jitterDelay(flags.MinDelay/2, flags.RandDelay/2)
+		start := time.Now()
+		n, err := d.downloadImage(ctx, img, imgPath)
+		if ctx.Err() != nil {
+			return // Interrupted. The download was aborted, and the image is left pending.
+		}
 
-		sent := downloadImage(imgDir, url)
+		if err == nil {
+			if img.Checksum, img.Size, err = fsutil.FileChecksum(imgPath); err != nil {
+				err = fmt.Errorf("failed to checksum image file: %w", err)
+			}
+		}
 
-		progressbar.UpdateProgressDisplay(titles, imgCon.IncrementDownloaded)
+		if err != nil {
+			img.Status = types.ImageFailed
+			img.Error = err.Error()
+			imgCon.IncrementFailed()
+			c.updateManifest(manifest, url, job.StatusFailed, err)
+			obs.Notify(progress.ImageFailed{Container: imgCon, URL: url, Err: err})
 
-		/* Post-delay. Only delay the next image request, if one was sent in the first place. */
-		if sent {
-			jitterDelay(flags.MinDelay/2, flags.RandDelay/2)
+			failedMu.Lock()
+			failed = append(failed, failedJob{dir: imgDir, job: j})
+			failedMu.Unlock()
+		} else {
+			img.Status = types.ImageDownloaded
+			imgCon.AddBytes(n) // Only completed downloads count, so that failed attempts are not counted again once retried.
+			imgCon.IncrementDownloaded()
+			c.updateManifest(manifest, url, job.StatusDownloaded, nil)
+			obs.Notify(progress.ImageDownloaded{Container: imgCon, URL: url, Bytes: n, Duration: time.Since(start)})
 		}
+		done.check(imgCon)
 	}
 
-	downloadImgAsync := func(imgDir string, imgCon types.ImageContainer, url string) {
+	downloadImgAsync := func(imgDir string, j imageJob) {
+		/* Wait for a download slot, unless interrupted. */
+		select {
+		case sema <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
 		wg.Add(1)
-		sema <- struct{}{}
-		go func(url string) {
+		go func() {
 			defer wg.Done()
 			defer func() { <-sema }()
 
-			downloadImg(imgDir, imgCon, url)
-		}(url)
+			downloadImg(imgDir, j)
+		}()
+	}
+
+	outputDir, err := fsutil.CreateOutputDir(manifest.Dir())
+	if err != nil {
+		return err
 	}
+	c.saveManifest(manifest) // Save the job before the first download, so it can be resumed from the start.
+	defer c.saveManifest(manifest)
 
-	outputDir := fsutil.CreateOutputDir(flags.OutputDir)
+	/* Clean up after previously interrupted downloads, which cannot be resumed. */
+	removed, err := fsutil.RemoveStalePartials(outputDir)
+	for _, p := range removed {
+		c.log(logf.LOG_WARNING, "Removed stale partial file: %s", p)
+	}
+	if err != nil {
+		c.log(logf.LOG_ERROR, "Failed to remove stale partial files in %s: %v", outputDir, err)
+	}
 
-	fmt.Println(":: Showing progress...")
-	progressbar.ShowProgress(titles)
+	/*
+		Returns the directory of the title or episode `imgCon`, creating it (and starting
+		the download clock of `imgCon`) when its first image comes in.
+	*/
+	dirs := make(map[types.ImageContainer]string)
+	containerDir := func(imgCon types.ImageContainer) (string, error) {
+		if dir, ok := dirs[imgCon]; ok {
+			return dir, nil
+		}
 
-	/* For each title... */
-	for _, title := range titles {
-		titleDir := fsutil.CreateTitleDir(outputDir, title.Name)
-		title.Start = time.Now()
-
-		/* Handle movies seperately, since they have no episodes. */
-		if title.Category == types.CategoryMovie {
-			URLs := title.Images.URLs()
-			for _, url := range URLs {
-				if !flags.NoAsync {
-					downloadImgAsync(titleDir, title, url)
-				} else {
-					downloadImg(titleDir, title, url)
+		var (
+			dir string
+			err error
+		)
+		switch ic := imgCon.(type) {
+		case *types.Title:
+			dir, err = fsutil.CreateTitleDir(outputDir, ic.Name)
+			ic.Start = time.Now()
+			obs.Notify(progress.TitleStarted{Title: ic})
+		case *types.Episode:
+			titleDir, ok := dirs[ic.Title]
+			if !ok {
+				if titleDir, err = fsutil.CreateTitleDir(outputDir, ic.Title.Name); err != nil {
+					return "", err
 				}
+				ic.Title.Start = time.Now()
+				dirs[ic.Title] = titleDir
+				obs.Notify(progress.TitleStarted{Title: ic.Title})
 			}
+			dir, err = fsutil.CreateEpisodeDir(titleDir, ic.Name)
+			ic.Start = time.Now()
+		}
+		if err != nil {
+			return "", err
+		}
+		dirs[imgCon] = dir
+
+		return dir, nil
+	}
+
+	obs.Notify(progress.DownloadStarted{})
 
-			continue // Go to next title.
+	/* Produce images to download... */
+	jobs := make(chan imageJob)
+	go func() {
+		defer close(jobs)
+
+		produce(ctx, func(j imageJob) bool {
+			select {
+			case jobs <- j:
+				return true
+			case <-ctx.Done():
+				return false // Interrupted. Don't produce any more images.
+			}
+		})
+	}()
+
+	/* ...and download them as they come in. */
+	var dirErr error
+	for j := range jobs {
+		imgDir, err := containerDir(j.imgCon)
+		if err != nil {
+			if dirErr == nil {
+				dirErr = err
+				cancel(err) // Without its directory, no image can be downloaded. Abort, but keep draining the jobs.
+			}
+			continue
 		}
 
-		/* For each episode... */
-		for _, episode := range title.Episodes {
-			episodeDir := fsutil.CreateEpisodeDir(titleDir, episode.Name)
+		if c.async {
+			downloadImgAsync(imgDir, j)
+		} else {
+			downloadImg(imgDir, j)
+		}
+	}
+
+	wg.Wait()
 
-			URLs := episode.Images.URLs()
-			episode.Start = time.Now()
-			for _, url := range URLs {
-				if !flags.NoAsync {
-					downloadImgAsync(episodeDir, episode, url)
+	/*
+		Retry the failed images once more, after a longer delay than between attempts, unless the downloads were aborted.
+		Retried images are processed anew, so their failures are undone first.
+		Images left unprocessed by an interruption are pending again, like any other interrupted image.
+	*/
+	if retries := failed; c.retryPassDelay > 0 && len(retries) > 0 && ctx.Err() == nil {
+		failed = nil
+		c.log(logf.LOG_WARNING, "Retrying %d failed image(s) in %s", len(retries), c.retryPassDelay)
+		obs.Notify(progress.RetryStarted{Images: len(retries), Delay: c.retryPassDelay})
+		if err := sleep(ctx, c.retryPassDelay); err == nil {
+			for _, r := range retries {
+				r.job.img.Status = types.ImagePending
+				r.job.img.Error = ""
+				r.job.imgCon.DecrementFailed()
+				obs.Notify(progress.ImageRetrying{Container: r.job.imgCon, URL: r.job.img.Url})
+
+				if c.async {
+					downloadImgAsync(r.dir, r.job)
 				} else {
-					downloadImg(episodeDir, episode, url)
+					downloadImg(r.dir, r.job)
 				}
 			}
+			wg.Wait()
+		}
+	}
+
+	/*
+		Report titles and episodes left without any image to download, (e.g., all of them were skipped)
+		along with those held back for the retry pass.
+	*/
+	done.release()
+	for _, title := range titles {
+		for _, episode := range title.Episodes {
+			done.check(episode)
+		}
+		done.check(title)
+	}
+
+	var runErr error
+	switch {
+	case d.breaker.Tripped():
+		runErr = ErrRateLimited
+	case dirErr != nil:
+		runErr = dirErr
+	}
+	obs.Notify(progress.DownloadFinished{Err: runErr})
+
+	return runErr
+}
+
+/* Notifies an observer once titles and episodes are done. (See `progress.ContainerDone`) */
+type doneTracker struct {
+	obs        progress.Observer             // Receives the events.
+	done       map[types.ImageContainer]bool // Titles and episodes already reported as done.
+	holdFailed bool                          // If true, titles and episodes with failed images are not reported yet. (See `release()`)
+	mu         sync.Mutex                    // Prevents bad writes from concurrent downloads.
+}
+
+/*
+Returns a tracker notifying the observer `obs`.
+If `holdFailed` is enabled, titles and episodes with failed images are held back until released,
+since their failed images may still be retried.
+*/
+func newDoneTracker(obs progress.Observer, holdFailed bool) *doneTracker {
+	return &doneTracker{
+		obs:        obs,
+		done:       make(map[types.ImageContainer]bool),
+		holdFailed: holdFailed,
+	}
+}
+
+/* Stops holding back titles and episodes with failed images. They are reported by their next check. */
+func (t *doneTracker) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.holdFailed = false
+}
+
+/*
+Notifies the observer of the tracker `t`, if all images of the title or episode `imgCon` were processed
+and no more images will be found, unless it was already reported or is held back. The title of an episode is checked too.
+*/
+func (t *doneTracker) check(imgCon types.ImageContainer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, ic := range []types.ImageContainer{imgCon, imgCon.GetTitle()} {
+		if t.done[ic] || ic.IsScraping() || ic.Downloaded()+ic.Skipped()+ic.Failed() < ic.Total() || (t.holdFailed && ic.Failed() > 0) {
+			continue
 		}
+		t.done[ic] = true
+		t.obs.Notify(progress.ContainerDone{Container: ic})
 	}
+}
+
+/*
+Records the status `status` of the image at URL `url` in the job manifest `m`,
+along with the error `err` for failed images. Logs any error encountered while saving `m`.
+*/
+func (c *Client) updateManifest(m *job.Manifest, url string, status job.Status, err error) {
+	if err := m.Update(url, status, err); err != nil {
+		c.log(logf.LOG_ERROR, "Failed to update job manifest (%s): %v", m.Path(), err)
+	}
+}
 
-	if !flags.NoAsync {
-		wg.Wait()
+/* Saves unsaved changes of the job manifest `m`. Logs any error encountered. */
+func (c *Client) saveManifest(m *job.Manifest) {
+	if err := m.Save(); err != nil {
+		c.log(logf.LOG_ERROR, "Failed to save job manifest (%s): %v", m.Path(), err)
 	}
 }
 
+/* Downloads images, sharing state across concurrent downloads. */
+type downloader struct {
+	policy    retryPolicy        // Policy for retrying transient failures.
+	breaker   *circuitBreaker    // Aborts all downloads after repeated rate-limit responses.
+	limiter   *ratelimit.Limiter // Limits the rate of image requests across all downloads.
+	bandwidth *ratelimit.Limiter // Limits the bytes received by all downloads. (bytes/second) Nil, if unlimited.
+	client    *http.Client       // Client shared by all downloads, pooling their connections.
+	log       Logger             // Receives logs of failures and retries.
+}
+
 /*
-Downloads the image found at the URL `url` to the directory `imgDir`,
-and returns whether the request to download the image was made.
+Downloads the image `img` to the path `imgPath`, recording its attempts and the status code of its last response.
+Every attempt waits for the rate limiter of the downloader `d` first.
 
-Although not strictly enforced, `imgDir` is expected to refer to an "Episode directory"
+Although not strictly enforced, `imgPath` is expected to be in an "Episode directory"
 for Anime and TV Series titles or a "Title directory" for Movie titles.
 Logs errors for locating the image, file creation, or copying content to a file, if encountered.
+
+Transient failures (rate limits, server errors, timeouts and connection resets) are retried
+according to the retry policy of `d`. Rate-limit responses are recorded by the circuit breaker of `d`,
+and no more attempts are made once it has tripped.
+
+If the context `ctx` is canceled, the request is aborted and any partially written image is kept,
+so a later download can resume it.
+Returns the size of the downloaded image, (Bytes received by failed attempts are not counted)
+and the error which made the download fail, if any.
 */
-func downloadImage(imgDir string, url string) bool {
-	imgFilename := path.Base(url)
-	imgPath := filepath.Join(imgDir, imgFilename)
-	sent := false
+func (d *downloader) downloadImage(ctx context.Context, img *types.Image, imgPath string) (int64, error) {
+	url := img.Url
 
 	/* If file already exists, don't overwrite and log as a error. */
 	if _, err := os.Stat(imgPath); err == nil {
-		logf.LogErrorf(logf.LOG_ERROR, "Inconsistent file state: %s was absent during initial check, but exists now", imgPath)
-		return sent
+		d.log(logf.LOG_ERROR, "Inconsistent file state: %s was absent during initial check, but exists now", imgPath)
+		return 0, fmt.Errorf("file appeared during download: %s", imgPath)
 	} else if !os.IsNotExist(err) {
-		logf.LogErrorf(logf.LOG_ERROR, "Failed to stat file (%s): %v", imgPath, err)
-		return sent
+		d.log(logf.LOG_ERROR, "Failed to stat file (%s): %v", imgPath, err)
+		return 0, err
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	for attempt := 0; ; attempt++ {
+		if err := d.limiter.Wait(ctx); err != nil {
+			return 0, err // Interrupted while waiting.
+		}
+
+		img.Attempts++
+		n, retryAfter, err := d.fetchImage(ctx, img, imgPath)
+		if err == nil {
+			if info, err := os.Stat(imgPath); err == nil {
+				n = info.Size() // Resumed attempts receive only the rest of the image.
+			}
+			return n, nil
+		}
+
+		/* Interrupted, or aborted by the circuit breaker. */
+		if ctx.Err() != nil {
+			if !errors.Is(context.Cause(ctx), ErrRateLimited) {
+				d.log(logf.LOG_WARNING, "Download interrupted, kept partial image for resuming: %s", imgPath)
+			}
+			return 0, context.Cause(ctx)
+		}
+
+		var statusErr *ErrBadStatus
+		transient := isTransientError(err) || (errors.As(err, &statusErr) && isTransientStatus(statusErr.Code))
+		if !transient || attempt >= d.policy.retries {
+			d.log(logf.LOG_ERROR, "Failed to download image (%s) after %d attempt(s): %v", url, attempt+1, err)
+			return 0, err
+		}
+
+		/* Wait before retrying. */
+		delay := d.policy.backoff(attempt+1, retryAfter)
+		d.log(logf.LOG_WARNING, "Retrying image (%s) in %s [%d/%d]: %v", url, delay.Round(time.Millisecond), attempt+1, d.policy.retries, err)
+		if err := sleep(ctx, delay); err != nil {
+			return 0, err // Interrupted while waiting.
+		}
+	}
+}
+
+/*
+Makes a single attempt at downloading the image `img` to the path `imgPath`, recording the status code of the response in `img`,
+and reporting the server's response to the circuit breaker and rate limiter of `d`.
+If a previous attempt left a resumable partial image, only its missing bytes are requested.
+The image contents are read within the bandwidth limit of `d` (if any).
+
+Returns the amount of bytes received, the delay requested by the server through a Retry-After header (0, if none),
+and any error encountered.
+*/
+func (d *downloader) fetchImage(ctx context.Context, img *types.Image, imgPath string) (int64, time.Duration, error) {
+	url := img.Url
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		logf.LogErrorf(logf.LOG_ERROR, "Failed to create HTTP request: %v", err)
-		return sent
+		return 0, 0, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 6.1) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/41.0.2228.0 Safari/537.36")
-	req.Header.Set("Referer", "https://fancaps.net")
 
-	client := &http.Client{}
-	res, err := client.Do(req)
-	sent = true
+	/* Resume a previously interrupted download, if possible. */
+	p := loadPartial(imgPath, url)
+	p.setRangeHeaders(req)
+
+	start := time.Now()
+	res, err := d.client.Do(req)
 	if err != nil {
-		logf.LogErrorf(logf.LOG_ERROR, "Failed to perform HTTP request: %v", err)
-		return sent
+		return 0, 0, fmt.Errorf("failed to perform HTTP request: %w", err)
 	}
 	defer res.Body.Close()
+	img.HTTPStatus = res.StatusCode
 
-	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusForbidden {
-		fmt.Fprintln(os.Stderr,
-			"You are being rate-limited. Try again later."+"\n"+
-				"Hint: Try setting `--parallel-downloads` to a lower value.")
-		os.Exit(2)
-	} else if res.StatusCode != http.StatusOK {
-		logf.LogErrorf(logf.LOG_ERROR, "Bad status code: %d for URL: %s", res.StatusCode, url)
-		return sent
+	if isRateLimitStatus(res.StatusCode) {
+		d.breaker.RecordRateLimit()
+		d.limiter.OnThrottle()
+		return 0, parseRetryAfter(res.Header.Get("Retry-After"), time.Now()), &ErrBadStatus{Code: res.StatusCode, URL: url}
 	}
+	d.breaker.RecordSuccess()
 
-	/* Open file to copy image contents to. */
-	file, err := os.Create(imgPath)
-	if err != nil {
-		logf.LogErrorf(logf.LOG_ERROR, "Failed to create file (%s): %v", imgPath, err)
-		return sent
+	/* The partial image changed size or went away. Start over. */
+	if res.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		p.discard()
+		return 0, 0, fmt.Errorf("%w: bad status code: %d for URL: %s", errStalePartial, res.StatusCode, url)
 	}
-	defer file.Close()
 
-	/* Copy the response body to the file. */
-	_, err = io.Copy(file, res.Body)
-	if err != nil {
-		logf.LogErrorf(logf.LOG_ERROR, "Failed to copy image contents to file (%s): %v", imgPath, err)
-		return sent
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		return 0, parseRetryAfter(res.Header.Get("Retry-After"), time.Now()), &ErrBadStatus{Code: res.StatusCode, URL: url}
+	}
+	d.limiter.OnSuccess(time.Since(start)) // Latency up to the response headers. (i.e., time to first byte)
+
+	/* Write the image to its file. */
+	var body io.Reader = res.Body
+	if d.bandwidth != nil {
+		body = ratelimit.NewReader(ctx, body, d.bandwidth)
 	}
 
-	return sent
+	n, err := writeImageFile(p, url, imgPath, res, body)
+	return n, 0, err
 }
 
 /*
-Sleeps for a minimum of `minDelay` time and a random amount
-ranging from 0 (no random delay) to `randDelay` time.
-Returns the amount of time slept.
+Writes the body `body` of the response `res` for the image at URL `url` to the partial file of
+the partial image `p`, and moves it to the path `imgPath` once complete.
 
-In this way, `randDelay` acts as the maximum amount of random delay possible.
+The partial file is synced to disk before being moved, so `imgPath` never refers to
+a partially written image. On failure, the partial file is kept, so the download can be resumed.
+Returns the amount of bytes written, even on failure.
 */
-func jitterDelay(minDelay, randDelay time.Duration) time.Duration {
-	var r time.Duration
-	if randDelay > 0 {
-		r = time.Duration(rand.Int63n(int64(randDelay)))
+func writeImageFile(p *partialImage, url, imgPath string, res *http.Response, body io.Reader) (n int64, err error) {
+	f, err := p.open(res, url)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open partial file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+		}
+	}()
+
+	n, err = io.Copy(f, body)
+	if err != nil {
+		return n, fmt.Errorf("failed to copy image contents: %w", err)
+	}
+	if res.ContentLength >= 0 && n != res.ContentLength {
+		return n, fmt.Errorf("%w: got %d of %d bytes", errIncompleteImage, n, res.ContentLength)
 	}
-	jitter := minDelay + r
 
-	time.Sleep(jitter)
+	if err = f.Sync(); err != nil {
+		return n, fmt.Errorf("failed to sync partial file: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return n, fmt.Errorf("failed to close partial file: %w", err)
+	}
+	if err = p.complete(imgPath); err != nil {
+		return n, fmt.Errorf("failed to rename partial file: %w", err)
+	}
 
-	return jitter
+	return n, nil
 }
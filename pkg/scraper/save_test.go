@@ -0,0 +1,275 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sheeper.com/fancaps-scraper-go/pkg/job"
+	"sheeper.com/fancaps-scraper-go/pkg/progress"
+	"sheeper.com/fancaps-scraper-go/pkg/ratelimit"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+func TestDownloadEvents(t *testing.T) {
+	movie := &types.Title{Category: types.CategoryMovie, Name: "Movie", Url: "movie-url", Images: &types.Images{}}
+	for _, url := range []string{"https://cdni.fancaps.net/file/1.jpg", "https://cdni.fancaps.net/file/2.jpg"} {
+		movie.Images.AddURL(url)
+		movie.IncrementImageTotal()
+	}
+
+	rec := &progress.Recorder{}
+	c := newTestClient(t, http.StatusOK, "image")
+	err := c.Download(context.Background(), []*types.Title{movie}, DownloadOptions{OutputDir: t.TempDir(), Observer: rec})
+	if err != nil {
+		t.Fatalf("Download() returned unexpected error: %v", err)
+	}
+
+	want := map[string]int{
+		"download_started":  1,
+		"title_started":     1,
+		"image_downloaded":  2,
+		"image_failed":      0,
+		"container_done":    1,
+		"download_finished": 1,
+	}
+	for name, n := range want {
+		if got := rec.Count(name); got != n {
+			t.Errorf("Count(%q) = %d; want %d", name, got, n)
+		}
+	}
+
+	if got := movie.Bytes(); got != int64(2*len("image")) {
+		t.Errorf("Bytes() = %d; want %d", got, 2*len("image"))
+	}
+	for _, img := range movie.Images.List() {
+		if img.Status != types.ImageDownloaded || img.Size != int64(len("image")) || img.HTTPStatus != http.StatusOK || img.Attempts != 1 || img.Checksum == "" || img.Path == "" {
+			t.Errorf("image = %+v; want a downloaded image of %d bytes after 1 attempt", img, len("image"))
+		}
+	}
+
+	events := rec.Events()
+	if _, ok := events[0].(progress.DownloadStarted); !ok {
+		t.Errorf("first event = %s; want download_started", events[0].Name())
+	}
+	if _, ok := events[len(events)-1].(progress.DownloadFinished); !ok {
+		t.Errorf("last event = %s; want download_finished", events[len(events)-1].Name())
+	}
+	for _, e := range events {
+		if d, ok := e.(progress.ImageDownloaded); ok && d.Bytes != int64(len("image")) {
+			t.Errorf("ImageDownloaded.Bytes = %d; want %d", d.Bytes, len("image"))
+		}
+	}
+}
+
+func TestDownloadFailures(t *testing.T) {
+	movie := &types.Title{Category: types.CategoryMovie, Name: "Movie", Url: "movie-url", Images: &types.Images{}}
+	movie.Images.AddURL("https://cdni.fancaps.net/file/1.jpg")
+	movie.IncrementImageTotal()
+
+	rec := &progress.Recorder{}
+	c := newTestClient(t, http.StatusNotFound, "")
+	if err := c.Download(context.Background(), []*types.Title{movie}, DownloadOptions{OutputDir: t.TempDir(), Observer: rec}); err != nil {
+		t.Fatalf("Download() returned unexpected error: %v", err)
+	}
+
+	if movie.Downloaded() != 0 || movie.Failed() != 1 {
+		t.Errorf("Downloaded() = %d, Failed() = %d; want 0 downloaded, 1 failed", movie.Downloaded(), movie.Failed())
+	}
+	if img := movie.Images.List()[0]; img.Status != types.ImageFailed || img.Error == "" || img.HTTPStatus != http.StatusNotFound {
+		t.Errorf("image = %+v; want a failed image with its reason", img)
+	}
+	if got := rec.Count("container_done"); got != 1 {
+		t.Errorf("Count(\"container_done\") = %d; want 1", got)
+	}
+}
+
+func TestRetryPass(t *testing.T) {
+	movie := &types.Title{Category: types.CategoryMovie, Name: "Movie", Url: "movie-url", Images: &types.Images{}}
+	movie.Images.AddURL("https://cdni.fancaps.net/file/1.jpg")
+	movie.IncrementImageTotal()
+
+	rt := &flakyTransport{stubTransport: stubTransport{code: http.StatusOK, body: "image"}, failures: 1}
+	c, err := New(WithTransport(rt), WithRetries(0, 0), WithRetryPass(time.Millisecond), WithRateLimit(ratelimit.ModeFixed, 1000, 0, 0))
+	if err != nil {
+		t.Fatalf("New() returned unexpected error: %v", err)
+	}
+
+	rec := &progress.Recorder{}
+	if err := c.Download(context.Background(), []*types.Title{movie}, DownloadOptions{OutputDir: t.TempDir(), Observer: rec}); err != nil {
+		t.Fatalf("Download() returned unexpected error: %v", err)
+	}
+
+	if movie.Downloaded() != 1 || movie.Failed() != 0 {
+		t.Errorf("Downloaded() = %d, Failed() = %d; want 1 downloaded, 0 failed", movie.Downloaded(), movie.Failed())
+	}
+	if img := movie.Images.List()[0]; img.Status != types.ImageDownloaded || img.Error != "" || img.Attempts != 2 {
+		t.Errorf("image = %+v; want a downloaded image after 2 attempts", img)
+	}
+
+	want := map[string]int{
+		"image_failed":     1,
+		"retry_started":    1,
+		"image_retrying":   1,
+		"image_downloaded": 1,
+		"container_done":   1,
+	}
+	for name, n := range want {
+		if got := rec.Count(name); got != n {
+			t.Errorf("Count(%q) = %d; want %d", name, got, n)
+		}
+	}
+
+	var totals progress.Totals
+	for _, e := range rec.Events() {
+		totals.Add(e)
+	}
+	if totals.Downloaded != 1 || totals.Failed != 0 {
+		t.Errorf("totals = %+v; want 1 downloaded, 0 failed", totals)
+	}
+
+	/* The movie is not done until its failed image was retried. */
+	var names []string
+	for _, e := range rec.Events() {
+		names = append(names, e.Name())
+	}
+	if done, retried := slices.Index(names, "container_done"), slices.Index(names, "image_downloaded"); done < retried {
+		t.Errorf("events = %q; want container_done after the retried image_downloaded", names)
+	}
+}
+
+/* Answers the first request with a body cut short of its content length, and every other request like `stubTransport`. */
+type truncatingTransport struct {
+	stubTransport
+	requests atomic.Int32 // Amount of requests so far.
+}
+
+func (t *truncatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.stubTransport.RoundTrip(req)
+	if t.requests.Add(1) == 1 {
+		res.Body = io.NopCloser(strings.NewReader(t.body[:len(t.body)/2]))
+	}
+	return res, err
+}
+
+func TestRetryPassBytes(t *testing.T) {
+	movie := &types.Title{Category: types.CategoryMovie, Name: "Movie", Url: "movie-url", Images: &types.Images{}}
+	movie.Images.AddURL("https://cdni.fancaps.net/file/1.jpg")
+	movie.IncrementImageTotal()
+
+	rt := &truncatingTransport{stubTransport: stubTransport{code: http.StatusOK, body: "image"}}
+	c, err := New(WithTransport(rt), WithRetries(0, 0), WithRetryPass(time.Millisecond), WithRateLimit(ratelimit.ModeFixed, 1000, 0, 0))
+	if err != nil {
+		t.Fatalf("New() returned unexpected error: %v", err)
+	}
+
+	rec := &progress.Recorder{}
+	if err := c.Download(context.Background(), []*types.Title{movie}, DownloadOptions{OutputDir: t.TempDir(), Observer: rec}); err != nil {
+		t.Fatalf("Download() returned unexpected error: %v", err)
+	}
+	if rec.Count("image_retrying") != 1 || movie.Downloaded() != 1 {
+		t.Fatalf("Downloaded() = %d after %d retries; want 1 image downloaded after 1 retry", movie.Downloaded(), rec.Count("image_retrying"))
+	}
+
+	/* Bytes of the failed attempt are not counted along with those of the retry. */
+	if got := movie.Bytes(); got != int64(len("image")) {
+		t.Errorf("Bytes() = %d; want %d", got, len("image"))
+	}
+}
+
+func TestRetryBytes(t *testing.T) {
+	movie := &types.Title{Category: types.CategoryMovie, Name: "Movie", Url: "movie-url", Images: &types.Images{}}
+	movie.Images.AddURL("https://cdni.fancaps.net/file/1.jpg")
+	movie.IncrementImageTotal()
+
+	rt := &truncatingTransport{stubTransport: stubTransport{code: http.StatusOK, body: "image"}}
+	c, err := New(WithTransport(rt), WithRetries(1, 0), WithRetryPass(0), WithRateLimit(ratelimit.ModeFixed, 1000, 0, 0))
+	if err != nil {
+		t.Fatalf("New() returned unexpected error: %v", err)
+	}
+
+	rec := &progress.Recorder{}
+	if err := c.Download(context.Background(), []*types.Title{movie}, DownloadOptions{OutputDir: t.TempDir(), Observer: rec}); err != nil {
+		t.Fatalf("Download() returned unexpected error: %v", err)
+	}
+	if rt.requests.Load() != 2 || movie.Downloaded() != 1 {
+		t.Fatalf("Downloaded() = %d after %d requests; want 1 image downloaded after 2 requests", movie.Downloaded(), rt.requests.Load())
+	}
+
+	/* Only the bytes of the completed image count, not those of the failed attempt before it. */
+	if got := movie.Bytes(); got != int64(len("image")) {
+		t.Errorf("Bytes() = %d; want %d", got, len("image"))
+	}
+	for _, e := range rec.Events() {
+		if downloaded, ok := e.(progress.ImageDownloaded); ok && downloaded.Bytes != int64(len("image")) {
+			t.Errorf("image_downloaded with %d bytes; want %d", downloaded.Bytes, len("image"))
+		}
+	}
+}
+
+func TestDownloadUnfinishedJob(t *testing.T) {
+	newMovie := func() *types.Title {
+		movie := &types.Title{Category: types.CategoryMovie, Name: "Movie", Url: "movie-url", Images: &types.Images{}}
+		movie.Images.AddURL("https://cdni.fancaps.net/file/1.jpg")
+		movie.IncrementImageTotal()
+		return movie
+	}
+
+	/* Failed downloads do not leave an unfinished job behind... */
+	dir := t.TempDir()
+	if err := newTestClient(t, http.StatusNotFound, "").Download(context.Background(), []*types.Title{newMovie()}, DownloadOptions{OutputDir: dir}); err != nil {
+		t.Fatalf("Download() returned unexpected error: %v", err)
+	}
+	if err := newTestClient(t, http.StatusNotFound, "").Download(context.Background(), []*types.Title{newMovie()}, DownloadOptions{OutputDir: dir}); err != nil {
+		t.Fatalf("Download() after a job with failed images returned unexpected error: %v", err)
+	}
+
+	/* ...but interrupted downloads do, with their images still pending... */
+	if err := job.New(dir, []*types.Title{newMovie()}).Save(); err != nil {
+		t.Fatalf("Save() returned unexpected error: %v", err)
+	}
+
+	/* ...which a new job does not replace. */
+	rec := &progress.Recorder{}
+	err := newTestClient(t, http.StatusOK, "image").Download(context.Background(), []*types.Title{newMovie()}, DownloadOptions{OutputDir: dir, Observer: rec})
+	if !errors.Is(err, job.ErrUnfinished) {
+		t.Errorf("Download() error = %v; want ErrUnfinished", err)
+	}
+	if got := rec.Count("download_started"); got != 0 {
+		t.Errorf("Count(\"download_started\") = %d; want 0", got)
+	}
+}
+
+func TestDownloadFrameNames(t *testing.T) {
+	newMovie := func() *types.Title {
+		movie := &types.Title{Category: types.CategoryMovie, Name: "Movie", Url: "movie-url", Images: &types.Images{}}
+		movie.Images.AddURL("https://cdni.fancaps.net/file/1.jpg")
+		movie.IncrementImageTotal()
+		return movie
+	}
+
+	/* Resumed jobs name their files by frame, as the job was started with, regardless of the options. */
+	movie := newMovie()
+	manifest := job.New(t.TempDir(), []*types.Title{movie})
+	manifest.FrameNames = true
+	err := newTestClient(t, http.StatusOK, "image").Download(context.Background(), []*types.Title{movie}, DownloadOptions{Manifest: manifest})
+	if err != nil {
+		t.Fatalf("Download() returned unexpected error: %v", err)
+	}
+	if img := movie.Images.List()[0]; filepath.Base(img.Path) != "0001_1.jpg" {
+		t.Errorf("image path = %q; want a file named 0001_1.jpg", img.Path)
+	}
+
+	/* Frames may still shift while streaming. */
+	err = newTestClient(t, http.StatusOK, "image").Download(context.Background(), []*types.Title{newMovie()}, DownloadOptions{OutputDir: t.TempDir(), Stream: true, FrameNames: true})
+	if err == nil {
+		t.Error("Download() while streaming with frame names returned no error; want an error")
+	}
+}
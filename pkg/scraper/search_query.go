@@ -1,80 +1,11 @@
 package scraper
 
 import (
-	"fmt"
 	"net/url"
-	"os"
-	"strings"
 
-	"github.com/gocolly/colly"
-	"golang.org/x/sync/errgroup"
-	"sheeper.com/fancaps-scraper-go/pkg/cli"
 	"sheeper.com/fancaps-scraper-go/pkg/types"
-	"sheeper.com/fancaps-scraper-go/pkg/ui"
-	"sheeper.com/fancaps-scraper-go/pkg/ui/prompt"
 )
 
-/* Rendered help text for a search query prompt. */
-var queryHelpPrompt = strings.Join([]string{
-	ui.HelpStyle.Render("Type the name of a movie, TV series, or anime you'd like to search for."),
-	ui.HelpStyle.Render(`(e.g., "Predator", "Family Guy", "Hunter x Hunter", etc.)`),
-	ui.HelpStyle.Render("Tip: You can enter just part of a title to search."),
-}, "\n")
-
-/*
-Returns a list of search URLs to be scraped based on queries `queries` and categories `categories`.
-
-If no queries have been specified, this function will prompt the user for queries and
-validate them incrementally, and validates all queries in parallel otherwise.
-*/
-func GetSearchURLs(queries []string, categories []types.Category) []string {
-	searchURLs := []string{}
-	if len(queries) == 0 { // Prompt and validate search URLs incrementally.
-		for len(queries) == 0 || prompt.YesNoPrompt("Enter another query? [y/N]: ", "") {
-			query := prompt.TextPrompt("Enter Search Query: ", queryHelpPrompt)
-			if strings.TrimSpace(query) == "" {
-				fmt.Fprintln(os.Stderr, ui.ErrStyle.Render("search query cannot be empty.")+"\n")
-				continue
-			}
-
-			url := BuildQueryURL(query, categories)
-			if !titleExists(url) {
-				fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("no titles found for query `%s`.")+"\n", query)
-				continue
-			}
-			fmt.Printf(ui.SuccessStyle.Render("Found titles for query: `%s`")+"\n", query)
-			searchURLs = append(searchURLs, url)
-			queries = append(queries, query)
-		}
-	} else { // Validate search URLs all at once.
-		for _, query := range queries {
-			if strings.TrimSpace(query) == "" { // fancaps.net considers empty queries as valid and returns a massive list otherwise.
-				fmt.Fprintln(os.Stderr, "search query cannot be empty.")
-				os.Exit(1)
-			}
-			url := BuildQueryURL(query, categories)
-			searchURLs = append(searchURLs, url)
-		}
-
-		var eg errgroup.Group
-		for i, url := range searchURLs {
-			i, url := i, url // https://golang.org/doc/faq#closures_and_goroutines
-			eg.Go(func() error {
-				if !titleExists(url) {
-					return fmt.Errorf("no titles found for query `%s`", queries[i])
-				}
-				return nil
-			})
-		}
-		if err := eg.Wait(); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
-	}
-
-	return searchURLs
-}
-
 /*
 Returns a URL which will be used to scrape titles using query `query`,
 searching only categories in `categories`.
@@ -97,29 +28,3 @@ func BuildQueryURL(query string, categories []types.Category) string {
 
 	return "https://fancaps.net/search.php" + "?" + params.Encode()
 }
-
-/* Returns true, if a title exists in the URL `searchURL`, and returns false otherwise. */
-func titleExists(searchURL string) bool {
-	titleExists := false
-	flags := cli.Flags()
-
-	scraperOpts := GetScraperOpts(flags)
-	c := colly.NewCollector(scraperOpts...)
-
-	/* Search the results of each category. */
-	c.OnHTML("div.single_post_content > table", func(e *colly.HTMLElement) {
-		/* Title found. */
-		e.ForEachWithBreak("h4 > a", func(_ int, _ *colly.HTMLElement) bool {
-			titleExists = true
-			return false // Stop searching for more titles.
-		})
-	})
-
-	c.Visit(searchURL)
-
-	if !flags.NoAsync {
-		c.Wait()
-	}
-
-	return titleExists
-}
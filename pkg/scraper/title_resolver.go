@@ -0,0 +1,152 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/*
+Returns a non-empty, unique list of titles resolved from the title arguments `titleArgs`,
+searching only categories in `categories` when resolving title names.
+
+Each title argument is either a fancaps.net title URL or the exact name of a title (case-insensitive).
+Title URLs are resolved directly without any network requests,
+while title names are matched against the search results of the name.
+Titles are returned in the order their arguments were given.
+
+Returns `ErrEmptyQuery`, if a title argument is blank, `ErrNotFound`, if no title matches a title name,
+and any error encountered while searching.
+*/
+func (c *Client) ResolveTitles(ctx context.Context, titleArgs []string, categories []types.Category) ([]*types.Title, error) {
+	var (
+		titles []*types.Title              // Resolved titles.
+		seen   = make(map[string]struct{}) // Duplicate titles protection.
+	)
+
+	for _, arg := range titleArgs {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			return nil, fmt.Errorf("title: %w", ErrEmptyQuery)
+		}
+
+		var resolved []*types.Title
+		if isTitleURL(arg) {
+			t, err := titleFromURL(arg)
+			if err != nil {
+				return nil, err
+			}
+			resolved = []*types.Title{t}
+		} else {
+			var err error
+			if resolved, err = c.matchTitleName(ctx, arg, categories); err != nil {
+				return nil, err
+			}
+			if len(resolved) == 0 {
+				return nil, fmt.Errorf("%w named `%s`", ErrNotFound, arg)
+			}
+		}
+
+		for _, t := range resolved {
+			if _, exists := seen[t.Url]; !exists {
+				seen[t.Url] = struct{}{}
+				titles = append(titles, t)
+			}
+		}
+	}
+
+	return titles, nil
+}
+
+/*
+Returns true, if `s` is a URL to a title on fancaps.net,
+and returns false otherwise.
+*/
+func isTitleURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	if host != allowedDomains {
+		return false
+	}
+
+	return strings.Contains(u.Path, "/movies/") ||
+		strings.Contains(u.Path, "/tv/") ||
+		strings.Contains(u.Path, "/anime/")
+}
+
+/* Returns a title from its fancaps.net title URL `titleURL`. */
+func titleFromURL(titleURL string) (*types.Title, error) {
+	category, err := getCategory(titleURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Title{
+		Category: category,
+		Name:     getTitleNameFromURL(titleURL),
+		Url:      titleURL,
+		Images:   &types.Images{},
+	}, nil
+}
+
+/*
+Returns the name of a title from its fancaps.net title URL `titleURL`.
+If the name is unable to be extracted for whatever reason, the URL is returned.
+
+For example,
+
+	"https://fancaps.net/anime/showimages.php?3435-Neon_Genesis_Evangelion" -> "Neon Genesis Evangelion"
+	"https://fancaps.net/movies/MovieImages.php?name=Inception_2010&movieid=1337" -> "Inception 2010"
+*/
+func getTitleNameFromURL(titleURL string) string {
+	u, err := url.Parse(titleURL)
+	if err != nil {
+		return titleURL
+	}
+
+	name := u.Query().Get("name") // Movie titles.
+	if name == "" {               // Anime and TV Series titles.
+		if _, after, found := strings.Cut(u.RawQuery, "-"); found {
+			name, _ = url.QueryUnescape(after)
+		}
+	}
+
+	name = strings.TrimSpace(strings.ReplaceAll(name, "_", " "))
+	if name == "" {
+		return titleURL
+	}
+
+	return name
+}
+
+/*
+Returns all titles named `name` (case-insensitive) found by searching for `name`,
+searching only categories in `categories`.
+Returns any error encountered while searching.
+*/
+func (c *Client) matchTitleName(ctx context.Context, name string, categories []types.Category) ([]*types.Title, error) {
+	var titles []*types.Title
+	err := c.withPageSlot(ctx, func() (err error) {
+		titles, err = c.scrapeTitles(ctx, BuildQueryURL(name, categories))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*types.Title
+	for _, t := range titles {
+		if strings.EqualFold(strings.TrimSpace(t.Name), name) {
+			matches = append(matches, t)
+		}
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,113 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+func TestIsTitleURL(t *testing.T) {
+	tests := []struct {
+		input    string // String to check.
+		expected bool   // True if the string is a title URL.
+	}{
+		{"https://fancaps.net/anime/showimages.php?3435-Neon_Genesis_Evangelion", true},
+		{"https://fancaps.net/tv/showimages.php?1234-The_Office", true},
+		{"https://fancaps.net/movies/MovieImages.php?name=Inception_2010&movieid=1337", true},
+		{"http://www.fancaps.net/anime/showimages.php?3435-Neon_Genesis_Evangelion", true},
+
+		{"Naruto", false},
+		{"fancaps.net/anime/showimages.php?3435-Neon_Genesis_Evangelion", false},
+		{"ftp://fancaps.net/anime/showimages.php?3435", false},
+		{"https://example.com/anime/showimages.php?3435-Naruto", false},
+		{"https://fancaps.net.example.com/anime/showimages.php?3435-Naruto", false},
+		{"https://fancaps.net/search.php?q=Naruto", false},
+		{"https://fancaps.net/", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := isTitleURL(tt.input); got != tt.expected {
+				t.Errorf("isTitleURL(%q) = %t; want %t", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetTitleNameFromURL(t *testing.T) {
+	tests := []struct {
+		input    string // Title URL.
+		expected string // Expected title name.
+	}{
+		{"https://fancaps.net/anime/showimages.php?3435-Neon_Genesis_Evangelion", "Neon Genesis Evangelion"},
+		{"https://fancaps.net/tv/showimages.php?1234-The_Office", "The Office"},
+		{"https://fancaps.net/anime/showimages.php?1-Re%3AZero", "Re:Zero"},
+		{"https://fancaps.net/anime/showimages.php?1-Naruto-Shippuden", "Naruto-Shippuden"},
+		{"https://fancaps.net/movies/MovieImages.php?name=Inception_2010&movieid=1337", "Inception 2010"},
+
+		/* Without a name, the URL is returned. */
+		{"https://fancaps.net/anime/showimages.php?3435", "https://fancaps.net/anime/showimages.php?3435"},
+		{"https://fancaps.net/movies/MovieImages.php?movieid=1337", "https://fancaps.net/movies/MovieImages.php?movieid=1337"},
+		{"https://fancaps.net/anime/showimages.php?1-_", "https://fancaps.net/anime/showimages.php?1-_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := getTitleNameFromURL(tt.input); got != tt.expected {
+				t.Errorf("getTitleNameFromURL(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTitleFromURL(t *testing.T) {
+	tests := []struct {
+		input     string         // Title URL.
+		expected  types.Category // Expected category.
+		expectErr bool           // True if an error is expected from the given input.
+	}{
+		{"https://fancaps.net/anime/showimages.php?3435-Neon_Genesis_Evangelion", types.CategoryAnime, false},
+		{"https://fancaps.net/tv/showimages.php?1234-The_Office", types.CategoryTV, false},
+		{"https://fancaps.net/movies/MovieImages.php?name=Inception_2010&movieid=1337", types.CategoryMovie, false},
+
+		{"https://fancaps.net/search.php?q=Naruto", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			title, err := titleFromURL(tt.input)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("titleFromURL(%q) expected error but got nil", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("titleFromURL(%q) returned unexpected error: %v", tt.input, err)
+			}
+			if title.Category != tt.expected || title.Url != tt.input || title.Images == nil {
+				t.Errorf("titleFromURL(%q) = %+v; want a %s title with its URL", tt.input, title, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveTitlesWWW(t *testing.T) {
+	const img = `<div class="row"><img class="imageFade" src="https://cdni.fancaps.net/file/fancaps-movieimages/1.jpg"></div>`
+	c := newTestClient(t, http.StatusOK, img)
+	ctx := context.Background()
+
+	titles, err := c.ResolveTitles(ctx, []string{"https://www.fancaps.net/movies/MovieImages.php?name=Akira&movieid=1"}, nil)
+	if err != nil || len(titles) != 1 {
+		t.Fatalf("ResolveTitles() = %v, %v; want one title", titles, err)
+	}
+
+	/* Pages of www.fancaps.net are visited, too. */
+	if images, err := c.Images(ctx, titles[0]); err != nil || len(images) != 1 {
+		t.Errorf("Images() = %v, %v; want the image of the title", images, err)
+	}
+}
@@ -1,59 +1,64 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/gocolly/colly"
-	"sheeper.com/fancaps-scraper-go/pkg/cli"
+	"sheeper.com/fancaps-scraper-go/pkg/logf"
 	"sheeper.com/fancaps-scraper-go/pkg/types"
-	"sheeper.com/fancaps-scraper-go/pkg/ui"
 )
 
 var seasonRegex = regexp.MustCompile(` Season (\d+)`) // Extracts a title's season number.
 
 /*
-Returns a non-empty, unique, sorted list of titles found through the URLs `searchURLs`,
-which are assumed to be validated (have at least one title associated with each URL).
+Returns a unique, sorted list of the titles found by searching for the query `query`,
+searching only categories in `categories`.
+Returns `ErrEmptyQuery`, if `query` is blank, `ErrNotFound`, if no titles were found,
+and any error encountered while requesting the search page.
 */
-func GetTitles(searchURLs []string) []*types.Title {
-	var (
-		titles   []*types.Title              // Scraped titles.
-		titlesMu sync.Mutex                  // Prevents overlapping "appends" to `titles`.
-		wg       sync.WaitGroup              // Synchronizes title scrapers.
-		seen     = make(map[string]struct{}) // Duplicate titles protection.
-	)
-
-	flags := cli.Flags()
+func (c *Client) Search(ctx context.Context, query string, categories []types.Category) ([]*types.Title, error) {
+	if strings.TrimSpace(query) == "" { // fancaps.net considers empty queries as valid and returns a massive list otherwise.
+		return nil, ErrEmptyQuery
+	}
 
-	for _, searchURL := range searchURLs {
-		wg.Add(1)
-		go func(searchURL string) {
-			defer wg.Done()
+	var titles []*types.Title
+	err := c.withPageSlot(ctx, func() (err error) {
+		titles, err = c.scrapeTitles(ctx, BuildQueryURL(query, categories))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(titles) == 0 {
+		return nil, fmt.Errorf("%w for query `%s`", ErrNotFound, query)
+	}
 
-			ts := scrapeTitles(searchURL, flags)
+	return MergeTitles(titles), nil
+}
 
-			titlesMu.Lock()
-			for _, t := range ts {
-				if _, exists := seen[t.Url]; !exists {
-					seen[t.Url] = struct{}{}
-					titles = append(titles, t)
-				}
+/*
+Returns the titles of the lists `lists`, without duplicates, sorted. (Case-insensitive)
+Precedence (Highest to Lowest): Category, Name + Season, Name.
+*/
+func MergeTitles(lists ...[]*types.Title) []*types.Title {
+	var (
+		titles []*types.Title              // Merged titles.
+		seen   = make(map[string]struct{}) // Duplicate titles protection.
+	)
+	for _, ts := range lists {
+		for _, t := range ts {
+			if _, exists := seen[t.Url]; !exists {
+				seen[t.Url] = struct{}{}
+				titles = append(titles, t)
 			}
-			titlesMu.Unlock()
-		}(searchURL)
+		}
 	}
-	wg.Wait()
 
-	/*
-		Sort found titles. (Case-insensitive)
-		Precedence (Highest to Lowest): Category, Name + Season, Name.
-	*/
 	sort.Slice(titles, func(i, j int) bool {
 		catI := titles[i].Category
 		catJ := titles[j].Category
@@ -82,31 +87,26 @@ func GetTitles(searchURLs []string) []*types.Title {
 		return baseNameI < baseNameJ
 	})
 
-	/* Debug: Print found titles. */
-	if flags.Debug {
-		maxTitleWidth := len(ui.GetLongestTitle(titles))
-
-		fmt.Println("\n\nFOUND TITLES:")
-		for _, t := range titles {
-			fmt.Printf("%-*s -> %s\n", maxTitleWidth, t.Name, t.Url)
-		}
-		fmt.Printf("\n\n")
-	}
-
 	return titles
 }
 
-/* Given a URL `searchURL`, return all titles found by FanCaps. */
-func scrapeTitles(searchURL string, flags cli.CLIFlags) []*types.Title {
+/*
+Given a URL `searchURL`, return all titles found by FanCaps,
+and any error encountered while requesting the search page.
+*/
+func (c *Client) scrapeTitles(ctx context.Context, searchURL string) ([]*types.Title, error) {
 	var titles []*types.Title
 
-	scraperOpts := GetScraperOpts(flags)
-	c := colly.NewCollector(scraperOpts...)
+	col := c.newCollector(ctx)
 
 	/* Extract title info. */
-	c.OnHTML("h4 > a", func(e *colly.HTMLElement) {
+	col.OnHTML("h4 > a", func(e *colly.HTMLElement) {
 		url := e.Request.AbsoluteURL(e.Attr("href"))
-		category := getCategory(url)
+		category, err := getCategory(url)
+		if err != nil {
+			c.log(logf.LOG_WARNING, "Skipping search result `%s`: %v", e.Text, err)
+			return
+		}
 		title := &types.Title{
 			Category: category,
 			Name:     e.Text,
@@ -116,34 +116,29 @@ func scrapeTitles(searchURL string, flags cli.CLIFlags) []*types.Title {
 		titles = append(titles, title)
 	})
 
-	if flags.Debug {
-		c.OnRequest(func(req *colly.Request) {
-			fmt.Printf("SEARCH QUERY URL: %s\n", req.URL.String())
-		})
-	}
-
-	c.Visit(searchURL)
+	col.OnRequest(func(req *colly.Request) {
+		c.debugf("SEARCH QUERY URL: %s\n", req.URL.String())
+	})
 
-	if !flags.NoAsync {
-		c.Wait()
-	}
+	err := c.visit(ctx, col, searchURL)
 
-	return titles
+	return titles, err
 }
 
-/* Return the category of a title based on its URL, `url`. */
-func getCategory(url string) types.Category {
+/*
+Return the category of a title based on its URL, `url`.
+Returns an error, if the URL does not belong to any category.
+*/
+func getCategory(url string) (types.Category, error) {
 	switch {
 	case strings.Contains(url, "/movies/"):
-		return types.CategoryMovie
+		return types.CategoryMovie, nil
 	case strings.Contains(url, "/tv/"):
-		return types.CategoryTV
+		return types.CategoryTV, nil
 	case strings.Contains(url, "/anime/"):
-		return types.CategoryAnime
+		return types.CategoryAnime, nil
 	default:
-		fmt.Fprintf(os.Stderr, "getCategory: couldn't extract category from url %s", url)
-		os.Exit(1)
-		return -1
+		return -1, fmt.Errorf("couldn't extract category from url %s", url)
 	}
 }
 
@@ -0,0 +1,39 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+func TestMergeTitles(t *testing.T) {
+	s2 := &types.Title{Category: types.CategoryAnime, Name: "Naruto Season 2", Url: "a2"}
+	s10 := &types.Title{Category: types.CategoryAnime, Name: "Naruto Season 10", Url: "a10"}
+	bleach := &types.Title{Category: types.CategoryAnime, Name: "bleach", Url: "b"}
+	movie := &types.Title{Category: types.CategoryMovie, Name: "Akira", Url: "m"}
+
+	got := MergeTitles([]*types.Title{movie, s10, s2}, []*types.Title{s2, bleach})
+
+	want := []*types.Title{bleach, s2, s10, movie}
+	if len(got) != len(want) {
+		t.Fatalf("MergeTitles() returned %d titles; want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("MergeTitles()[%d] = %q; want %q", i, got[i].Name, want[i].Name)
+		}
+	}
+}
+
+func TestSearchEmptyQuery(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatalf("New() returned unexpected error: %v", err)
+	}
+
+	if _, err := c.Search(context.Background(), "  ", nil); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("Search() error = %v; want ErrEmptyQuery", err)
+	}
+}
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"fmt"
+	"strings"
+)
+
 /* Enum for Categories. */
 type Category int
 
@@ -19,3 +24,14 @@ var CategoryName = map[Category]string{
 func (cat Category) String() string {
 	return CategoryName[cat]
 }
+
+/* Returns the category named `name` (case-insensitive), or an error if there is none. */
+func ParseCategory(name string) (Category, error) {
+	for cat, catName := range CategoryName {
+		if strings.EqualFold(strings.TrimSpace(name), catName) {
+			return cat, nil
+		}
+	}
+
+	return -1, fmt.Errorf("unknown category %q", name)
+}
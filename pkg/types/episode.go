@@ -4,11 +4,13 @@ import "time"
 
 /* An episode of a title. */
 type Episode struct {
-	Title  *Title    // Title to which the episode belongs to.
-	Name   string    // Name of the episode.
-	Url    string    // URL to the episode on fancaps.net.
-	Images *Images   // Image info about the episode. (Non-empty for Anime/TV Series Only)
-	Start  time.Time // Start time of episode download.
+	Title      *Title    // Title to which the episode belongs to.
+	Name       string    // Name of the episode.
+	Url        string    // URL to the episode on fancaps.net.
+	Images     *Images   // Image info about the episode. (Non-empty for Anime/TV Series Only)
+	Start      time.Time // Start time of episode download.
+	RangeIndex int       // Index of the episode range in `Title.EpisodeRanges` that selected the episode.
+	Position   Position  // Position of the episode on the pages of its title.
 }
 
 /* Returns the name of the episode `e`. */
@@ -31,6 +33,11 @@ func (e *Episode) GetDone() bool {
 	return e.Images.Done
 }
 
+/* Returns whether more images of episode `e` may still be found. */
+func (e *Episode) IsScraping() bool {
+	return e.Images.Scraping()
+}
+
 /* Returns the number of downloaded images for episode `e`. */
 func (e *Episode) Downloaded() uint32 {
 	return e.Images.Downloaded()
@@ -41,20 +48,27 @@ func (e *Episode) Skipped() uint32 {
 	return e.Images.Skipped()
 }
 
+/* Returns the number of images for episode `e` which failed to download. */
+func (e *Episode) Failed() uint32 {
+	return e.Images.Failed()
+}
+
 /* Returns the total number of images for episode `e`. */
 func (e *Episode) Total() uint32 {
 	return e.Images.Total()
 }
 
+/* Returns the amount of bytes received for images of episode `e`. */
+func (e *Episode) Bytes() int64 {
+	return e.Images.Bytes()
+}
+
 /* Marks the download of episode `e` as done.  */
 func (e *Episode) MarkDone() {
 	e.Images.Done = true
 }
 
-/*
-Increments the downloaded image counter of episode `e` and its title by 1,
-as well as the global downloaded image counter across all titles.
-*/
+/* Increments the downloaded image counter of episode `e` and its title by 1. */
 func (e *Episode) IncrementDownloaded() {
 	e.Images.mu.Lock()
 	defer e.Images.mu.Unlock()
@@ -63,10 +77,7 @@ func (e *Episode) IncrementDownloaded() {
 	e.Title.IncrementDownloaded()
 }
 
-/*
-Increments the skipped image counter of episode `e` and its title by 1,
-as well as the global skipped image counter across all titles.
-*/
+/* Increments the skipped image counter of episode `e` and its title by 1. */
 func (e *Episode) IncrementSkipped() {
 	e.Images.mu.Lock()
 	defer e.Images.mu.Unlock()
@@ -75,10 +86,34 @@ func (e *Episode) IncrementSkipped() {
 	e.Title.IncrementSkipped()
 }
 
-/*
-Increments the total image counter of episode `e` and its title by 1,
-as well as the global total image counter across all titles.
-*/
+/* Increments the failed image counter of episode `e` and its title by 1. */
+func (e *Episode) IncrementFailed() {
+	e.Images.mu.Lock()
+	defer e.Images.mu.Unlock()
+
+	e.Images.failed++
+	e.Title.IncrementFailed()
+}
+
+/* Decrements the failed image counter of episode `e` and its title by 1. (e.g., when a failed image is retried) */
+func (e *Episode) DecrementFailed() {
+	e.Images.mu.Lock()
+	defer e.Images.mu.Unlock()
+
+	e.Images.failed--
+	e.Title.DecrementFailed()
+}
+
+/* Adds `n` bytes received for an image to the byte counter of episode `e` and its title. */
+func (e *Episode) AddBytes(n int64) {
+	e.Images.mu.Lock()
+	defer e.Images.mu.Unlock()
+
+	e.Images.bytes += n
+	e.Title.AddBytes(n)
+}
+
+/* Increments the total image counter of episode `e` and its title by 1. */
 func (e *Episode) IncrementImageTotal() {
 	e.Images.mu.Lock()
 	defer e.Images.mu.Unlock()
@@ -86,3 +121,16 @@ func (e *Episode) IncrementImageTotal() {
 	e.Images.total++
 	e.Title.IncrementImageTotal()
 }
+
+/*
+Keeps only the images of episode `e` numbered `imgNums` (1-based),
+and decrements the total image counter of episode `e` and its title accordingly.
+*/
+func (e *Episode) SelectImages(imgNums []int) {
+	e.Images.mu.Lock()
+	defer e.Images.mu.Unlock()
+
+	removed := e.Images.retain(imgNums)
+	e.Images.total -= removed
+	e.Title.decrementImageTotal(removed)
+}
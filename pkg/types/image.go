@@ -1,17 +1,21 @@
 package types
 
 import (
+	"slices"
 	"sync"
 	"time"
 )
 
 /* Image info on either a title or episode. */
 type Images struct {
-	urls       []string     // List of URLs to the images of a title or one of its episodes.
+	images     []imageEntry // Images of a title or one of its episodes, sorted by position.
 	downloaded uint32       // Amount of images downloaded.
 	skipped    uint32       // Amount of images skipped.
+	failed     uint32       // Amount of images which failed to download.
+	bytes      int64        // Amount of bytes received for images.
 	total      uint32       // Amount of images associated with a title or episode.
 	Done       bool         // If true, all images are processed.
+	scraping   bool         // If true, more images may still be found. (See `SetScraping()`)
 	mu         sync.RWMutex // Prevents bad writes from concurrent increments, while allowing multiple readers.
 }
 
@@ -20,21 +24,91 @@ type ImageContainer interface {
 	GetTitle() *Title
 	GetStart() time.Time
 	GetDone() bool
+	IsScraping() bool
 	Downloaded() uint32
 	Skipped() uint32
+	Failed() uint32
 	Total() uint32
+	Bytes() int64
 	MarkDone()
 	IncrementDownloaded()
 	IncrementSkipped()
+	IncrementFailed()
+	DecrementFailed()
+	AddBytes(n int64)
 	IncrementImageTotal()
+	SelectImages(imgNums []int)
 }
 
-/* Returns the URLs of the images `imgs`. */
+/*
+An image of a title or episode, along with the state of its download.
+Only the URL of the full-size image is required. Other fields are filled in as the image is scraped and downloaded.
+*/
+type Image struct {
+	PageUrl    string      // URL of the page on which the image was found.
+	ThumbUrl   string      // URL of the thumbnail shown on the page.
+	Url        string      // URL of the full-size image.
+	Frame      int         // Frame index of the image. (1-based, see `Images.Frames()`)
+	Path       string      // Path of the image file. Empty, until the image is downloaded or skipped.
+	Size       int64       // Size of the image file. (bytes)
+	Checksum   string      // SHA-256 checksum of the image file, in hex. Empty, unless the image was downloaded.
+	HTTPStatus int         // Status code of the last response to a download request. 0, if there was none.
+	Attempts   int         // Amount of download requests made for the image.
+	Status     ImageStatus // Status of the image.
+	Error      string      // Reason of the last failure. (Failed images only)
+}
+
+/* An image held by a title or episode. */
+type imageEntry struct {
+	img    *Image   // The image.
+	pos    Position // Position of the image on the pages of its title or episode.
+	pinned bool     // If true, the frame index of the image is fixed. Otherwise, it is given by its index in the sorted images.
+}
+
+/* Returns the images of `imgs`, in order of their frame indexes. */
+func (imgs *Images) List() []*Image {
+	imgs.mu.RLock()
+	defer imgs.mu.RUnlock()
+
+	list := make([]*Image, len(imgs.images))
+	for i, e := range imgs.images {
+		list[i] = e.img
+	}
+
+	return list
+}
+
+/* Returns the URLs of the images `imgs`, in order of their frame indexes. */
 func (imgs *Images) URLs() []string {
 	imgs.mu.RLock()
 	defer imgs.mu.RUnlock()
 
-	return imgs.urls
+	urls := make([]string, len(imgs.images))
+	for i, e := range imgs.images {
+		urls[i] = e.img.Url
+	}
+
+	return urls
+}
+
+/*
+Returns the frame indexes (1-based) of the images `imgs`, in the order of `URLs()`.
+
+The frame index of an image is its number among all images of its title or episode, in the order of the site.
+Frame indexes are stable between runs, once all pages of the title or episode were scraped,
+and are kept when images are selected. (See `SelectImages()`)
+Unlike the `Frame` field of images, frame indexes are safe to read while more images are found.
+*/
+func (imgs *Images) Frames() []int {
+	imgs.mu.RLock()
+	defer imgs.mu.RUnlock()
+
+	frames := make([]int, len(imgs.images))
+	for i, e := range imgs.images {
+		frames[i] = e.img.Frame
+	}
+
+	return frames
 }
 
 /* Returns the number of downloaded images for `imgs`. */
@@ -53,6 +127,22 @@ func (imgs *Images) Skipped() uint32 {
 	return imgs.skipped
 }
 
+/* Returns the number of images which failed to download. */
+func (imgs *Images) Failed() uint32 {
+	imgs.mu.RLock()
+	defer imgs.mu.RUnlock()
+
+	return imgs.failed
+}
+
+/* Returns the amount of bytes received for the images `imgs`. */
+func (imgs *Images) Bytes() int64 {
+	imgs.mu.RLock()
+	defer imgs.mu.RUnlock()
+
+	return imgs.bytes
+}
+
 /* Returns the number of images. */
 func (imgs *Images) Total() uint32 {
 	imgs.mu.RLock()
@@ -61,10 +151,103 @@ func (imgs *Images) Total() uint32 {
 	return imgs.total
 }
 
-/* Adds a URL. */
+/* Returns true, if more images of `imgs` may still be found. */
+func (imgs *Images) Scraping() bool {
+	imgs.mu.RLock()
+	defer imgs.mu.RUnlock()
+
+	return imgs.scraping
+}
+
+/*
+Sets whether more images of `imgs` may still be found,
+i.e., whether images are downloaded while their pages are still being scraped.
+*/
+func (imgs *Images) SetScraping(scraping bool) {
+	imgs.mu.Lock()
+	defer imgs.mu.Unlock()
+
+	imgs.scraping = scraping
+}
+
+/* Adds an image with the URL `url` after all other images, as the next frame. */
 func (imgs *Images) AddURL(url string) {
+	imgs.Add(&Image{Url: url})
+}
+
+/*
+Adds the image `img` after all other images.
+If `img` has a frame index (e.g., read back from a file), it is kept. Otherwise, `img` is added as the next frame.
+*/
+func (imgs *Images) Add(img *Image) {
+	imgs.mu.Lock()
+	defer imgs.mu.Unlock()
+
+	imgs.images = append(imgs.images, imageEntry{img: img, pos: imgs.nextPosition(), pinned: img.Frame > 0})
+	imgs.renumber(len(imgs.images) - 1)
+}
+
+/*
+Inserts the image `img` found at the position `pos`, keeping images sorted by position,
+and returns its frame index, which may still shift while earlier pages are scraped.
+*/
+func (imgs *Images) Insert(img *Image, pos Position) int {
 	imgs.mu.Lock()
 	defer imgs.mu.Unlock()
 
-	imgs.urls = append(imgs.urls, url)
+	i, _ := slices.BinarySearchFunc(imgs.images, pos, func(e imageEntry, pos Position) int {
+		return e.pos.Compare(pos)
+	})
+	imgs.images = slices.Insert(imgs.images, i, imageEntry{img: img, pos: pos})
+	imgs.renumber(i)
+
+	return img.Frame
+}
+
+/*
+Updates the frame indexes of the images of `imgs` from the `i`-th image on, except for fixed ones.
+Callers are expected to hold the lock of `imgs`.
+*/
+func (imgs *Images) renumber(i int) {
+	for ; i < len(imgs.images); i++ {
+		if !imgs.images[i].pinned {
+			imgs.images[i].img.Frame = i + 1
+		}
+	}
+}
+
+/*
+Returns the position following the last image of `imgs`.
+Callers are expected to hold the lock of `imgs`.
+*/
+func (imgs *Images) nextPosition() Position {
+	if len(imgs.images) == 0 {
+		return Position{}
+	}
+
+	last := imgs.images[len(imgs.images)-1].pos
+	return Position{Page: last.Page, Index: last.Index + 1}
+}
+
+/*
+Keeps only the images numbered `imgNums` (1-based, in order of frame indexes) and
+returns the number of images removed. Numbers out of range are ignored.
+Kept images keep their frame indexes.
+
+Counters are left untouched. Callers are expected to hold the lock of `imgs`.
+*/
+func (imgs *Images) retain(imgNums []int) uint32 {
+	kept := make([]imageEntry, 0, len(imgNums))
+	for _, n := range imgNums {
+		if n >= 1 && n <= len(imgs.images) {
+			e := imgs.images[n-1]
+			e.pinned = true
+			kept = append(kept, e)
+		}
+	}
+
+	removed := uint32(len(imgs.images) - len(kept))
+	imgs.images = kept
+
+	return removed
 }
@@ -0,0 +1,20 @@
+package types
+
+import "cmp"
+
+/*
+Position of an episode or image on the pages listing it, as found while scraping.
+Sorting by position gives the order of the site, regardless of the order in which pages were received.
+*/
+type Position struct {
+	Page  int // Number of the page. (0-based, in order of pagination)
+	Index int // Index on the page. (0-based)
+}
+
+/* Returns -1, 0 or +1, if the position `p` comes before, at or after the position `q`, respectively. */
+func (p Position) Compare(q Position) int {
+	if c := cmp.Compare(p.Page, q.Page); c != 0 {
+		return c
+	}
+	return cmp.Compare(p.Index, q.Index)
+}
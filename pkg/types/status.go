@@ -0,0 +1,39 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+/* Enum for image statuses. */
+type ImageStatus int
+
+const (
+	ImagePending    ImageStatus = iota // The image was not processed yet, or its download was interrupted.
+	ImageDownloaded                    // The image was downloaded.
+	ImageSkipped                       // The image already existed, so it was not downloaded.
+	ImageFailed                        // The download of the image failed.
+)
+
+var ImageStatusName = map[ImageStatus]string{
+	ImagePending:    "pending",
+	ImageDownloaded: "downloaded",
+	ImageSkipped:    "skipped",
+	ImageFailed:     "failed",
+}
+
+/* Convert an image status enumeration to its corresponding string representation. */
+func (s ImageStatus) String() string {
+	return ImageStatusName[s]
+}
+
+/* Returns the image status named `name` (case-insensitive), or an error if there is none. */
+func ParseImageStatus(name string) (ImageStatus, error) {
+	for s, statusName := range ImageStatusName {
+		if strings.EqualFold(strings.TrimSpace(name), statusName) {
+			return s, nil
+		}
+	}
+
+	return -1, fmt.Errorf("unknown image status %q", name)
+}
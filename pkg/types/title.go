@@ -4,12 +4,13 @@ import "time"
 
 /* A Movie, TV Series, or Anime title. */
 type Title struct {
-	Episodes []*Episode // Episodes of the title.
-	Category Category   // Category of the title.
-	Name     string     // Name of the title.
-	Url      string     // URL to the title on fancaps.net.
-	Images   *Images    // Image info about the title. (Non-empty for Movie Titles Only)
-	Start    time.Time  // Start time of title download.
+	Episodes      []*Episode // Episodes of the title.
+	Category      Category   // Category of the title.
+	Name          string     // Name of the title.
+	Url           string     // URL to the title on fancaps.net.
+	Images        *Images    // Image info about the title. (Non-empty for Movie Titles Only)
+	Start         time.Time  // Start time of title download.
+	EpisodeRanges []string   // Comma-separated episode ranges the episodes were selected with, in order.
 }
 
 /* Returns the name of the title `t`. */
@@ -32,34 +33,37 @@ func (t *Title) GetDone() bool {
 	return t.Images.Done
 }
 
-/* Returns the number of downloaded images from title `t`. */
-func (t *Title) Downloaded() uint32 {
-	var downloaded uint32
-	for _, e := range t.Episodes {
-		downloaded += e.Downloaded()
-	}
+/* Returns whether more images of title `t` may still be found. */
+func (t *Title) IsScraping() bool {
+	return t.Images.Scraping()
+}
 
-	return downloaded
+/*
+Returns the number of downloaded images from title `t`.
+The counters of a title include those of its episodes. (See `Episode.IncrementDownloaded()`)
+*/
+func (t *Title) Downloaded() uint32 {
+	return t.Images.Downloaded()
 }
 
-/* Returns the number of skipped images from title `t`. */
+/* Returns the number of skipped images from title `t`, including those of its episodes. */
 func (t *Title) Skipped() uint32 {
-	var skipped uint32
-	for _, e := range t.Episodes {
-		skipped += e.Skipped()
-	}
+	return t.Images.Skipped()
+}
 
-	return skipped
+/* Returns the number of images from title `t` which failed to download, including those of its episodes. */
+func (t *Title) Failed() uint32 {
+	return t.Images.Failed()
 }
 
-/* Returns the total number of images from title `t`. */
+/* Returns the total number of images from title `t`, including those of its episodes. */
 func (t *Title) Total() uint32 {
-	var total uint32
-	for _, e := range t.Episodes {
-		total += e.Total()
-	}
+	return t.Images.Total()
+}
 
-	return total
+/* Returns the amount of bytes received for images of title `t`, including those of its episodes. */
+func (t *Title) Bytes() int64 {
+	return t.Images.Bytes()
 }
 
 /* Marks the download of title `t` as done.  */
@@ -67,38 +71,72 @@ func (t *Title) MarkDone() {
 	t.Images.Done = true
 }
 
-/*
-Increments the downloaded image counter of title `t` by 1,
-as well as the global downloaded image counter across all titles.
-*/
+/* Increments the downloaded image counter of title `t` by 1. */
 func (t *Title) IncrementDownloaded() {
 	t.Images.mu.Lock()
 	defer t.Images.mu.Unlock()
 
 	t.Images.downloaded++
-	IncrementGlobalDownloadedCount()
 }
 
-/*
-Increments the skipped image counter of title `t` by 1,
-as well as the global skipped image counter across all titles.
-*/
+/* Increments the skipped image counter of title `t` by 1. */
 func (t *Title) IncrementSkipped() {
 	t.Images.mu.Lock()
 	defer t.Images.mu.Unlock()
 
 	t.Images.skipped++
-	IncrementGlobalSkippedCount()
 }
 
-/*
-Increments total image counter of title `t` by 1,
-as well as the global total image counter across all titles.
-*/
+/* Increments the failed image counter of title `t` by 1. */
+func (t *Title) IncrementFailed() {
+	t.Images.mu.Lock()
+	defer t.Images.mu.Unlock()
+
+	t.Images.failed++
+}
+
+/* Decrements the failed image counter of title `t` by 1. (e.g., when a failed image is retried) */
+func (t *Title) DecrementFailed() {
+	t.Images.mu.Lock()
+	defer t.Images.mu.Unlock()
+
+	t.Images.failed--
+}
+
+/* Adds `n` bytes received for an image to the byte counter of title `t`. */
+func (t *Title) AddBytes(n int64) {
+	t.Images.mu.Lock()
+	defer t.Images.mu.Unlock()
+
+	t.Images.bytes += n
+}
+
+/* Increments total image counter of title `t` by 1. */
 func (t *Title) IncrementImageTotal() {
 	t.Images.mu.Lock()
 	defer t.Images.mu.Unlock()
 
 	t.Images.total++
-	IncrementGlobalImageCount()
+}
+
+/*
+Keeps only the images of title `t` numbered `imgNums` (1-based),
+and decrements the total image counter of title `t` accordingly.
+
+Intended to be used only alongside titles with *NO* episodes. (e.g., Movies)
+*/
+func (t *Title) SelectImages(imgNums []int) {
+	t.Images.mu.Lock()
+	removed := t.Images.retain(imgNums)
+	t.Images.mu.Unlock()
+
+	t.decrementImageTotal(removed)
+}
+
+/* Decrements total image counter of title `t` by `n`. */
+func (t *Title) decrementImageTotal(n uint32) {
+	t.Images.mu.Lock()
+	defer t.Images.mu.Unlock()
+
+	t.Images.total -= n
 }
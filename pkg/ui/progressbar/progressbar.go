@@ -12,6 +12,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"golang.org/x/term"
 	"sheeper.com/fancaps-scraper-go/pkg/logf"
+	"sheeper.com/fancaps-scraper-go/pkg/progress"
 	"sheeper.com/fancaps-scraper-go/pkg/types"
 	"sheeper.com/fancaps-scraper-go/pkg/ui"
 )
@@ -40,23 +41,38 @@ var (
 	lastPrintedLines int        // Number of lines last printed by the progress display.
 )
 
+var rateSource func() float64 // Returns the current rate of image requests. (requests/second) Nil, if unknown.
+
+var (
+	totalMeter  throughputMeter                       // Measures the throughput of all downloads.
+	titleMeters = map[*types.Title]*throughputMeter{} // Measures the throughput of the downloads of each title.
+)
+
 var (
 	setOnce       sync.Once // Initializes certain progress variables.
 	downloadStart time.Time // Timestamp marking the start of the image download process for all titles.
-	ratioWidth    int       // Width taken by the ratio of completed/total images downloaded in the progress bar.
 )
 
-/* Displays progress bar(s) based on the state of the titles `titles`. */
+var ratioWidth int // Width taken by the ratio of completed/total images downloaded in the progress bar. (Grows along with the total)
+
+var runCounts types.Counts // Image counters of all titles shown, as of the latest call to `ShowProgress()`.
+
+/*
+Displays progress bar(s) based on the state of the titles `titles`.
+Totals may grow between calls, while images are still being found.
+*/
 func ShowProgress(titles []*types.Title) {
 	setOnce.Do(func() {
 		downloadStart = time.Now()
-		ratioWidth = 2*len(strconv.Itoa(int(types.GlobalTotalImages()))) + 3
 	})
 
 	progressMu.Lock()
 	defer progressMu.Unlock()
 
-	termWidth, _, err := term.GetSize(int(os.Stdin.Fd()))
+	runCounts = types.SumCounts(titles)
+	ratioWidth = 2*len(strconv.Itoa(int(runCounts.Total))) + 3
+
+	termWidth, _, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil {
 		logf.LogErrorf(logf.LOG_WARNING,
 			"Failed to get terminal width. %v\n"+
@@ -84,13 +100,26 @@ func ShowProgress(titles []*types.Title) {
 	lastPrintedLines += renderDownloadProgress(noContainer, termWidth)
 }
 
+/* Sets the function `rate` which returns the current rate of image requests, shown on the total progress line. */
+func SetRateSource(rate func() float64) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+
+	rateSource = rate
+}
+
 /*
-Increments the progress of an image container using incrementer function `incFunc`,
-and shows the progress of titles `titles`.
+Returns an observer showing the progress of titles `titles` on every event. (See `ShowProgress()`)
+Titles and episodes are marked as done once reported so, after their final progress was shown,
+which keeps their lines as they are. (See `progress.ContainerDone`)
 */
-func UpdateProgressDisplay(titles []*types.Title, incFunc func()) {
-	incFunc()
-	ShowProgress(titles)
+func NewObserver(titles []*types.Title) progress.Observer {
+	return progress.ObserverFunc(func(e progress.Event) {
+		ShowProgress(titles)
+		if done, ok := e.(progress.ContainerDone); ok {
+			done.Container.MarkDone()
+		}
+	})
 }
 
 /*
@@ -127,78 +156,92 @@ func renderDownloadProgress(imgCon types.ImageContainer, totalWidth int) int {
 	/*
 		Returns the string to be rendered at the right side of the progress bar.
 
-		`downloaded`, `total`, `start` indicate the number of downloaded units, total units, and
-		start time of the title or episode, respectively.
+		`eta`, `downloaded`, `skipped`, `failed`, `total` indicate the ETA string, and the number of downloaded, skipped,
+		failed and total units of the title or episode, respectively. Failed units count as processed,
+		but are also listed on their own.
 	*/
-	getRightText := func(downloaded, skipped, total uint32, start time.Time) string {
-		processed := downloaded + skipped
+	getRightText := func(eta string, downloaded, skipped, failed, total uint32) string {
+		processed := downloaded + skipped + failed
 
-		eta := getETAString(downloaded, skipped, total, start)
 		ratio := fmt.Sprintf("%*s", ratioWidth, fmt.Sprintf("(%d/%d)", processed, total))
 		pbar := createProgressBar(processed, total)
-		percentage := fmt.Sprintf("%*s", percentageWidth, fmt.Sprintf("%d%%", int(float64(processed)/float64(total)*100)))
-
-		return strings.Join([]string{
-			eta,
-			ratio,
-			pbar,
-			percentage,
-		}, " ")
+		percent := 0
+		if total > 0 {
+			percent = int(float64(processed) / float64(total) * 100)
+		}
+		percentage := fmt.Sprintf("%*s", percentageWidth, fmt.Sprintf("%d%%", percent))
+
+		parts := []string{eta, ratio, pbar, percentage}
+		if failed > 0 {
+			parts = append(parts, fmt.Sprintf("[%d failed]", failed))
+		}
+
+		return strings.Join(parts, " ")
 	}
 
 	var downloaded uint32
 	var skipped uint32
+	var failed uint32
 	var total uint32
 	switch imgCon.(type) {
 	case nil:
-		downloaded = types.GlobalDownloadedImages()
-		skipped = types.GlobalSkippedImages()
-		total = types.GlobalTotalImages()
+		downloaded = runCounts.Downloaded
+		skipped = runCounts.Skipped
+		failed = runCounts.Failed
+		total = runCounts.Total
 	case *types.Title, *types.Episode:
 		downloaded = imgCon.Downloaded()
 		skipped = imgCon.Skipped()
+		failed = imgCon.Failed()
 		total = imgCon.Total()
 	}
 
+	/* Titles and the total progress line also show their throughput, with a byte-weighted ETA. */
 	leftText := ""
 	rightText := ""
 	switch imgCon.(type) {
 	case nil:
-		leftText = getLeftText("Total: ", totalSpacing)
-		rightText = getRightText(downloaded, skipped, total, downloadStart)
+		totalName := "Total: "
+		if rateSource != nil {
+			totalName = fmt.Sprintf("Total (%.2f req/s): ", rateSource())
+		}
+		bytes := runCounts.Bytes
+		rate := totalMeter.rate(time.Now(), bytes)
+
+		leftText = getLeftText(totalName, totalSpacing)
+		rightText = getThroughputString(bytes, rate) + " " +
+			getRightText(getByteETAString(downloaded, skipped, failed, total, downloadStart, bytes, rate), downloaded, skipped, failed, total)
 	case *types.Title:
+		meter, ok := titleMeters[imgCon.GetTitle()]
+		if !ok {
+			meter = &throughputMeter{}
+			titleMeters[imgCon.GetTitle()] = meter
+		}
+		bytes := imgCon.Bytes()
+		rate := meter.rate(time.Now(), bytes)
+
 		leftText = getLeftText(imgCon.GetName(), titleSpacing)
-		rightText = getRightText(downloaded, skipped, total, imgCon.GetStart())
+		rightText = getThroughputString(bytes, rate) + " " +
+			getRightText(getByteETAString(downloaded, skipped, failed, total, imgCon.GetStart(), bytes, rate), downloaded, skipped, failed, total)
 	case *types.Episode:
 		baseEpisodeName := getBaseEpisodeName(imgCon.GetName())
 		leftText = getLeftText(baseEpisodeName, episodeSpacing)
-		rightText = getRightText(downloaded, skipped, total, imgCon.GetStart())
+		rightText = getRightText(getETAString(downloaded, skipped, failed, total, imgCon.GetStart()), downloaded, skipped, failed, total)
 	}
 
-	processed := downloaded + skipped
+	processed := downloaded + skipped + failed
+	scraping := imgCon != nil && imgCon.IsScraping() // If true, the total may still grow.
 
 	var lineStyle lipgloss.Style
 	switch {
 	case processed == 0:
 		// No styling.
-	case processed < total:
+	case processed < total || scraping:
 		lineStyle = ui.HighlightStyle
 	case processed == total:
 		lineStyle = ui.SuccessStyle
-
-		switch imgCon.(type) {
-		case nil:
-			// Do nothing. (We're on the total progress line, so we're done rendering!)
-		case *types.Title:
-			imgCon.MarkDone() // Mark title as done.
-		case *types.Episode:
-			imgCon.MarkDone() // Mark episode as done.
-
-			/* If an episode was fully processed, check if its title was processed to mark it too. */
-			parentTitle := imgCon.GetTitle()
-			if parentTitle.Downloaded()+parentTitle.Skipped() == parentTitle.Total() {
-				parentTitle.MarkDone()
-			}
+		if failed > 0 {
+			lineStyle = ui.ErrStyle // Processed, but some images are missing. (They may still be retried)
 		}
 	}
 
@@ -222,7 +265,10 @@ Creates a simple progress bar, where `amtProcessed` is the number of units
 processed so far and `total` is the total number of units.
 */
 func createProgressBar(amtProcessed uint32, total uint32) string {
-	completed := int(amtProcessed * progressbarWidth / total)
+	completed := 0
+	if total > 0 {
+		completed = int(amtProcessed * progressbarWidth / total)
+	}
 	remaining := int(progressbarWidth) - completed
 
 	return "[" +
@@ -232,35 +278,49 @@ func createProgressBar(amtProcessed uint32, total uint32) string {
 }
 
 /*
-Returns an ETA based on the start time `start`, and the number of downloaded, skipped,
-and total units, `downloaded`, `skipped`, `total`, respectively.
+Returns an ETA based on the start time `start`, and the number of downloaded, skipped, failed
+and total units, `downloaded`, `skipped`, `failed`, `total`, respectively.
 */
-func getETAString(downloaded, skipped, total uint32, start time.Time) string {
+func getETAString(downloaded, skipped, failed, total uint32, start time.Time) string {
 	/* If no previous download data available, estimate using global download data. */
 	if downloaded == 0 {
-		globalDownloaded := types.GlobalDownloadedImages()
+		globalDownloaded := runCounts.Downloaded
 		if globalDownloaded == 0 {
 			return "0s/--" // No download data available. No estimate!
 		}
 
 		globalElapsed := time.Since(downloadStart)
 		globalRate := float64(globalElapsed) / float64(globalDownloaded)
-		globalRemaining := time.Duration(globalRate * float64(total-downloaded-skipped)).Round(time.Second)
-		remainingWidth := len(globalRemaining.String())
+		globalRemaining := time.Duration(globalRate * float64(total-downloaded-skipped-failed)).Round(time.Second)
 
-		return fmt.Sprintf("%*s", remainingWidth+5, fmt.Sprintf("(0s/%s)", globalRemaining))
+		return formatETA(0, globalRemaining)
 	}
 
 	/* Otherwise, use local download data to estimate. */
 	elapsed := time.Since(start)
 	rate := float64(elapsed) / float64(downloaded)
-	remaining := time.Duration(rate * float64(total-downloaded-skipped)).Round(time.Second)
-	elapsed = elapsed.Round(time.Second)
+	remaining := time.Duration(rate * float64(total-downloaded-skipped-failed)).Round(time.Second)
+
+	return formatETA(elapsed.Round(time.Second), remaining)
+}
+
+/*
+Returns an ETA like `getETAString()`, but estimated from the amount of bytes received `bytes`
+at the current throughput `rate` (bytes/second), so that larger remaining images take longer.
+Falls back to `getETAString()`, until enough bytes were received for an estimate.
+*/
+func getByteETAString(downloaded, skipped, failed, total uint32, start time.Time, bytes int64, rate float64) string {
+	remaining, ok := getByteETA(downloaded, skipped, failed, total, bytes, rate)
+	if !ok {
+		return getETAString(downloaded, skipped, failed, total, start)
+	}
 
-	elapsedWidth := len(elapsed.String())
-	remainingWidth := len(remaining.String())
+	return formatETA(time.Since(start).Round(time.Second), remaining)
+}
 
-	return fmt.Sprintf("%*s", elapsedWidth+remainingWidth+3, fmt.Sprintf("(%s/%s)", elapsed, remaining))
+/* Returns the elapsed time `elapsed` and remaining time `remaining` as an ETA. (e.g., "(1m2s/30s)") */
+func formatETA(elapsed, remaining time.Duration) string {
+	return fmt.Sprintf("(%s/%s)", elapsed, remaining)
 }
 
 /*
@@ -0,0 +1,80 @@
+package progressbar
+
+import (
+	"fmt"
+	"time"
+)
+
+const throughputWindow = 5 * time.Second // Period over which the current throughput is measured.
+
+/* A sample of the bytes received so far, at a point in time. */
+type byteSample struct {
+	at    time.Time // Time of the sample.
+	bytes int64     // Bytes received by then.
+}
+
+/* Measures the current throughput of downloads over a sliding window. */
+type throughputMeter struct {
+	samples []byteSample // Samples within the window, oldest first.
+}
+
+/*
+Records the amount of bytes `bytes` received by the time `now`, and returns the throughput (bytes/second)
+of the meter `m` over its window. Returns 0, until at least two samples were recorded.
+*/
+func (m *throughputMeter) rate(now time.Time, bytes int64) float64 {
+	m.samples = append(m.samples, byteSample{at: now, bytes: bytes})
+
+	/* Drop samples outside of the window, while keeping the latest of them as a baseline. */
+	drop := 0
+	for drop < len(m.samples)-2 && now.Sub(m.samples[drop+1].at) >= throughputWindow {
+		drop++
+	}
+	m.samples = m.samples[drop:]
+
+	oldest := m.samples[0]
+	elapsed := now.Sub(oldest.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(bytes-oldest.bytes) / elapsed
+}
+
+/* Returns the amount of bytes `bytes` in a human-readable unit. (e.g., "12.3 MB") */
+func formatBytes(bytes float64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+
+	i := 0
+	for bytes >= 1000 && i < len(units)-1 {
+		bytes /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+
+	return fmt.Sprintf("%.1f %s", bytes, units[i])
+}
+
+/* Returns the amount of bytes received `bytes` and the throughput `rate` (bytes/second) as text. (e.g., "12.3 MB @ 1.2 MB/s") */
+func getThroughputString(bytes int64, rate float64) string {
+	return formatBytes(float64(bytes)) + " @ " + formatBytes(rate) + "/s"
+}
+
+/*
+Returns the time left to process the remaining images of `total`, given that `downloaded`, `skipped` and `failed` images
+were processed so far, for which `bytes` bytes were received, at the current throughput `rate` (bytes/second).
+Remaining images are assumed to be as large as downloaded ones on average.
+Returns false, if there is not enough data for an estimate.
+*/
+func getByteETA(downloaded, skipped, failed, total uint32, bytes int64, rate float64) (time.Duration, bool) {
+	if downloaded == 0 || bytes == 0 || rate <= 0 {
+		return 0, false
+	}
+
+	avgBytes := float64(bytes) / float64(downloaded)
+	remainingBytes := avgBytes * float64(total-downloaded-skipped-failed)
+
+	return time.Duration(remainingBytes / rate * float64(time.Second)).Round(time.Second), true
+}
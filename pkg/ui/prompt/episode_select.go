@@ -27,52 +27,48 @@ func selectEpisodeHelp(title *types.Title) string {
 }
 
 /*
-Returns a list of titles with episodes selected by the user from titles `titles`.
+Returns a list of titles with episodes selected from titles `titles`.
+
+Titles covered by the episode range arguments `rangeArgs` (see `AssignEpisodeRanges()`)
+have their episodes selected without prompting, while the user is prompted for an episode range
+for every other (non-movie) title.
+If a range argument is invalid, this function prints an error and exits with code 1.
 If `debug` is enabled, print selected episodes and their titles.
 */
-func SelectEpisodes(titles []*types.Title, debug bool) []*types.Title {
+func SelectEpisodes(titles []*types.Title, rangeArgs []string, debug bool) []*types.Title {
+	assigned, err := AssignEpisodeRanges(titles, rangeArgs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("invalid episode ranges: %v")+"\n", err)
+		os.Exit(1)
+	}
+
 	for _, title := range titles {
-		err := fmt.Errorf("selectEpisodes error placeholder: you shouldn't be here")
+		if title.Category == types.CategoryMovie {
+			continue // Movies do not have episodes.
+		}
+
+		/* Range given by flag: Fail fast on invalid ranges instead of re-prompting. */
+		if userRange, ok := assigned[title]; ok {
+			if err := selectEpisodeRange(title, userRange, debug); err != nil {
+				fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("invalid episode range `%s` for %s: %v")+"\n", userRange, title.Name, err)
+				os.Exit(1)
+			}
+			continue
+		}
 
-		/* For each (non-movie) title, prompt the user for an episode range. */
-		for title.Category != types.CategoryMovie && err != nil {
+		/* Otherwise, prompt the user for an episode range. */
+		for {
 			selectEpisodePrompt := "Enter Episode Range for " + title.Name + ": "
 			userRange := TextPrompt(selectEpisodePrompt, selectEpisodeHelp(title))
 			if userRange == "" { // Default to all episodes if user doesn't specify a range.
 				userRange = "1-" + strconv.Itoa(getLastEpisodeNumber(title.Episodes))
 			}
 
-			var episodeRange []int
-			episodeRange, err = seq.ParseSequenceString(userRange, len(title.Episodes), debug)
-			if err != nil {
-				fmt.Fprintf(os.Stderr,
-					ui.ErrStyle.Render("%v")+"\n"+
-						ui.ErrStyle.Render("try again")+"\n\n",
-					err)
-			} else {
-				var selectedEpisodes []*types.Episode
-				lastFound := 0
-				for _, episodeNum := range episodeRange {
-					ep, index := getEpisodeByNumber(title.Episodes, lastFound, episodeNum) // Only need to search starting from the last found episode
-					if ep.Name != "" && !containsEpisode(selectedEpisodes, ep) {
-						selectedEpisodes = append(selectedEpisodes, ep)
-						lastFound = index
-					} else if containsEpisode(selectedEpisodes, ep) {
-						fmt.Fprintf(os.Stderr,
-							ui.ErrStyle.Render("warning: episode %d already selected for %s")+"\n"+
-								ui.ErrStyle.Render("skipping...")+"\n\n",
-							episodeNum, title.Name)
-					} else {
-						fmt.Fprintf(os.Stderr,
-							ui.ErrStyle.Render("error: couldn't find episode %d in %s[%d-%d]")+"\n"+
-								ui.ErrStyle.Render("skipping...")+"\n\n",
-							episodeNum, title.Name, lastFound, len(title.Episodes))
-						logf.LogErrorf(logf.LOG_ERROR, "Couldn't find episode %d in %s[%d-%d] Skipping...", episodeNum, title.Name, lastFound, len(title.Episodes))
-					}
-				}
-				title.Episodes = selectedEpisodes
-				err = nil
+			if err := selectEpisodeRange(title, userRange, debug); err != nil {
+				retryPrompt(err)
+				continue
 			}
+			break
 		}
 	}
 
@@ -89,6 +85,135 @@ func SelectEpisodes(titles []*types.Title, debug bool) []*types.Title {
 	return titles
 }
 
+/*
+Returns a map from (non-movie) titles of `titles` to the episode range assigned to them
+by the episode range arguments `rangeArgs`, or an error if the arguments are inconsistent.
+
+Each range argument is either a `title_name=episode_range` pair, assigning the range to the
+title of the same name (case-insensitive), or a bare episode range.
+Bare ranges are assigned positionally to the titles not named by a pair, in order,
+unless there is only a single bare range, in which case it is assigned to all of them.
+Titles without an assigned range are absent from the map.
+*/
+func AssignEpisodeRanges(titles []*types.Title, rangeArgs []string) (map[*types.Title]string, error) {
+	assigned := make(map[*types.Title]string)
+
+	var episodic []*types.Title
+	for _, t := range titles {
+		if t.Category != types.CategoryMovie {
+			episodic = append(episodic, t)
+		}
+	}
+
+	/* Assign named ranges. (Episode ranges never contain '=', so split on the last one.) */
+	var positional []string
+	for _, arg := range rangeArgs {
+		i := strings.LastIndex(arg, "=")
+		if i == -1 {
+			positional = append(positional, strings.TrimSpace(arg))
+			continue
+		}
+
+		name, userRange := strings.TrimSpace(arg[:i]), strings.TrimSpace(arg[i+1:])
+		found := false
+		for _, t := range episodic {
+			if strings.EqualFold(t.Name, name) {
+				if _, exists := assigned[t]; exists {
+					return nil, fmt.Errorf("multiple episode ranges given for %s", t.Name)
+				}
+				assigned[t] = userRange
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no selected title with episodes named `%s`", name)
+		}
+	}
+
+	/* Assign bare ranges to the remaining titles. */
+	var unnamed []*types.Title
+	for _, t := range episodic {
+		if _, ok := assigned[t]; !ok {
+			unnamed = append(unnamed, t)
+		}
+	}
+
+	switch {
+	case len(positional) == 0:
+		// Nothing to assign.
+	case len(positional) == 1:
+		for _, t := range unnamed {
+			assigned[t] = positional[0]
+		}
+	case len(positional) > len(unnamed):
+		return nil, fmt.Errorf("%d episode ranges given, but only %d titles left to assign them to", len(positional), len(unnamed))
+	default:
+		for i, userRange := range positional {
+			assigned[unnamed[i]] = userRange
+		}
+	}
+
+	return assigned, nil
+}
+
+/*
+Selects the episodes of title `title` specified by the episode range `userRange`.
+Returns an error if `userRange` is invalid, in which case `title` is left unchanged.
+If `debug` is enabled, print the selected episode numbers.
+*/
+func selectEpisodeRange(title *types.Title, userRange string, debug bool) error {
+	episodeRange, err := seq.ParseSequenceString(userRange, len(title.Episodes), debug)
+	if err != nil {
+		return err
+	}
+
+	/*
+		Map each episode number to the first comma-separated range that selects it,
+		so that image ranges may later be specified per episode range.
+	*/
+	var subRanges []string
+	rangeIndex := make(map[int]int, len(episodeRange))
+	for subRange := range strings.SplitSeq(userRange, ",") {
+		subRange = strings.TrimSpace(subRange)
+		nums, err := seq.ParseSequenceString(subRange, len(title.Episodes), false)
+		if err != nil {
+			return err
+		}
+		for _, n := range nums {
+			if _, exists := rangeIndex[n]; !exists {
+				rangeIndex[n] = len(subRanges)
+			}
+		}
+		subRanges = append(subRanges, subRange)
+	}
+
+	var selectedEpisodes []*types.Episode
+	lastFound := 0
+	for _, episodeNum := range episodeRange {
+		ep, index := getEpisodeByNumber(title.Episodes, lastFound, episodeNum) // Only need to search starting from the last found episode
+		if ep.Name != "" && !containsEpisode(selectedEpisodes, ep) {
+			ep.RangeIndex = rangeIndex[episodeNum]
+			selectedEpisodes = append(selectedEpisodes, ep)
+			lastFound = index
+		} else if containsEpisode(selectedEpisodes, ep) {
+			fmt.Fprintf(os.Stderr,
+				ui.ErrStyle.Render("warning: episode %d already selected for %s")+"\n"+
+					ui.ErrStyle.Render("skipping...")+"\n\n",
+				episodeNum, title.Name)
+		} else {
+			fmt.Fprintf(os.Stderr,
+				ui.ErrStyle.Render("error: couldn't find episode %d in %s[%d-%d]")+"\n"+
+					ui.ErrStyle.Render("skipping...")+"\n\n",
+				episodeNum, title.Name, lastFound, len(title.Episodes))
+			logf.LogErrorf(logf.LOG_ERROR, "Couldn't find episode %d in %s[%d-%d] Skipping...", episodeNum, title.Name, lastFound, len(title.Episodes))
+		}
+	}
+	title.Episodes = selectedEpisodes
+	title.EpisodeRanges = subRanges
+
+	return nil
+}
+
 /*
 Returns an episode from title `title` by the episode number `episodeNum`,
 starting from `start` and its index in `title`.
@@ -0,0 +1,71 @@
+package prompt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+func TestAssignEpisodeRanges(t *testing.T) {
+	naruto := &types.Title{Category: types.CategoryAnime, Name: "Naruto"}
+	bleach := &types.Title{Category: types.CategoryAnime, Name: "Bleach"}
+	equals := &types.Title{Category: types.CategoryTV, Name: "1+1=2"}
+	akira := &types.Title{Category: types.CategoryMovie, Name: "Akira"}
+	titles := []*types.Title{naruto, akira, bleach, equals}
+
+	tests := []struct {
+		name      string                  // Name of the test.
+		input     []string                // Episode range arguments.
+		expected  map[*types.Title]string // Expected ranges, by title.
+		expectErr bool                    // True if an error is expected from the given input.
+	}{
+		{"none", nil, map[*types.Title]string{}, false},
+		{"single range for all", []string{"1-3"}, map[*types.Title]string{naruto: "1-3", bleach: "1-3", equals: "1-3"}, false},
+		{"positional", []string{"1-3", "2", " 4- "}, map[*types.Title]string{naruto: "1-3", bleach: "2", equals: "4-"}, false},
+		{"fewer positional", []string{"1-3", "2"}, map[*types.Title]string{naruto: "1-3", bleach: "2"}, false},
+		{"named", []string{"bleach=1-5:2", "Naruto = 1-10"}, map[*types.Title]string{naruto: "1-10", bleach: "1-5:2"}, false},
+		{"named and positional", []string{"Bleach=5", "1-2", "3"}, map[*types.Title]string{naruto: "1-2", bleach: "5", equals: "3"}, false},
+		{"named and single range", []string{"1-2", "Naruto=7"}, map[*types.Title]string{naruto: "7", bleach: "1-2", equals: "1-2"}, false},
+		{"name with '='", []string{"1+1=2=1-4"}, map[*types.Title]string{equals: "1-4"}, false},
+
+		{"unknown name", []string{"Dragon Ball=1-3"}, nil, true},
+		{"movie name", []string{"Akira=1"}, nil, true},
+		{"named twice", []string{"Naruto=1", "naruto=2"}, nil, true},
+		{"too many positional", []string{"1", "2", "3", "4"}, nil, true},
+		{"too many positional after named", []string{"Naruto=1", "1", "2", "3"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AssignEpisodeRanges(titles, tt.input)
+
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("AssignEpisodeRanges(%q) expected error but got nil", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("AssignEpisodeRanges(%q) returned unexpected error: %v", tt.input, err)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("AssignEpisodeRanges(%q) = %s; want %s", tt.input, rangesString(got), rangesString(tt.expected))
+			}
+		})
+	}
+}
+
+/* Returns the episode ranges `ranges` as text, by title name. */
+func rangesString(ranges map[*types.Title]string) string {
+	var pairs []string
+	for t, r := range ranges {
+		pairs = append(pairs, t.Name+"="+r)
+	}
+
+	return "[" + strings.Join(pairs, " ") + "]"
+}
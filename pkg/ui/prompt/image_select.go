@@ -0,0 +1,184 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"sheeper.com/fancaps-scraper-go/pkg/seq"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+	"sheeper.com/fancaps-scraper-go/pkg/ui"
+)
+
+/* Image containers sharing an image range. */
+type imageGroup struct {
+	label      string                 // Describes the containers of the group. (e.g., "Episodes 2-4")
+	containers []types.ImageContainer // Titles or episodes whose images are selected.
+	counts     []int                  // Image count of each container.
+}
+
+/*
+Returns the rendered text for the image selection of title `title`
+for the image group `group`, with at most `max` images per container.
+*/
+func selectImageHelp(title *types.Title, group imageGroup, max int) string {
+	maxStr := strconv.Itoa(max)
+	target := "\"" + title.Name + "\""
+	if group.label != "" {
+		target = group.label + " of " + target
+	}
+
+	return strings.Join([]string{
+		ui.HelpStyle.Render("Provide a range of images you'd like to scrape from " + target),
+		ui.HelpStyle.Render("(e.g., 1-50, 100-200, 1-:2, " + "-" + maxStr + ",  etc.)"),
+		ui.HelpStyle.Render("Default: All. (1-" + maxStr + ") [Leave empty for default]"),
+		ui.HelpStyle.Render("Tip: Images beyond the image count of an episode are ignored for that episode."),
+	}, "\n")
+}
+
+/*
+Returns titles `titles` with their images trimmed to the image ranges selected for them.
+
+Image ranges are selected per comma-separated episode range of each title (see `Title.EpisodeRanges`),
+or once for titles without episodes (e.g., Movies).
+The i-th image range argument of `rangeArgs` applies to the i-th episode range of every title,
+unless there is only a single image range argument, in which case it applies to all of them.
+
+If no image range arguments are given and `interactive` is enabled,
+the user is prompted for an image range for each episode range instead.
+Otherwise, all images are kept for episode ranges without an image range.
+If a range argument is invalid, this function prints an error and exits with code 1.
+
+If `debug` is enabled, print the amount of selected images per title/episode.
+*/
+func SelectImages(titles []*types.Title, rangeArgs []string, interactive, debug bool) []*types.Title {
+	for _, title := range titles {
+		for i, group := range getImageGroups(title) {
+			maxCount := 0
+			for _, count := range group.counts {
+				maxCount = max(maxCount, count)
+			}
+			if maxCount == 0 {
+				continue // Nothing to select from.
+			}
+
+			switch {
+			case len(rangeArgs) > 0:
+				userRange := rangeArgs[0]
+				if len(rangeArgs) > 1 {
+					if i >= len(rangeArgs) {
+						continue // No image range for this episode range. Keep all images.
+					}
+					userRange = rangeArgs[i]
+				}
+
+				if err := selectImageRange(group, userRange, maxCount); err != nil {
+					fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("invalid image range `%s` for %s: %v")+"\n", userRange, title.Name, err)
+					os.Exit(1)
+				}
+
+			case interactive:
+				for {
+					selectImagePrompt := "Enter Image Range for " + title.Name + ": "
+					if group.label != "" {
+						selectImagePrompt = "Enter Image Range for " + title.Name + " (" + group.label + "): "
+					}
+
+					userRange := TextPrompt(selectImagePrompt, selectImageHelp(title, group, maxCount))
+					if userRange == "" { // Default to all images if user doesn't specify a range.
+						break
+					}
+
+					if err := selectImageRange(group, userRange, maxCount); err != nil {
+						retryPrompt(err)
+						continue
+					}
+					break
+				}
+			}
+		}
+	}
+
+	/* Debug: Print amount of selected images per title/episode. */
+	if debug {
+		fmt.Println("\nSELECTED IMAGES:")
+		for _, title := range titles {
+			if title.Category == types.CategoryMovie {
+				fmt.Printf("%s [%s] -> %d images\n", title.Name, title.Category, title.Images.Total())
+				continue
+			}
+
+			fmt.Printf("%s [%s] -> %d images\n", title.Name, title.Category, title.Total())
+			for _, episode := range title.Episodes {
+				fmt.Printf("\t%s -> %d images\n", episode.Name, episode.Total())
+			}
+		}
+	}
+
+	return titles
+}
+
+/*
+Returns the image groups of title `title`.
+
+Titles without episodes (e.g., Movies) form a single group,
+while the episodes of other titles are grouped by the episode range which selected them.
+*/
+func getImageGroups(title *types.Title) []imageGroup {
+	if title.Category == types.CategoryMovie {
+		return []imageGroup{{
+			containers: []types.ImageContainer{title},
+			counts:     []int{int(title.Images.Total())},
+		}}
+	}
+
+	/* Episodes not selected through episode ranges form a single group. */
+	if len(title.EpisodeRanges) == 0 {
+		group := imageGroup{}
+		for _, ep := range title.Episodes {
+			group.containers = append(group.containers, ep)
+			group.counts = append(group.counts, int(ep.Total()))
+		}
+		return []imageGroup{group}
+	}
+
+	groups := make([]imageGroup, len(title.EpisodeRanges))
+	for i, episodeRange := range title.EpisodeRanges {
+		groups[i].label = "Episodes " + episodeRange
+	}
+	for _, ep := range title.Episodes {
+		i := ep.RangeIndex
+		if i < 0 || i >= len(groups) {
+			i = 0
+		}
+		groups[i].containers = append(groups[i].containers, ep)
+		groups[i].counts = append(groups[i].counts, int(ep.Total()))
+	}
+
+	return groups
+}
+
+/*
+Trims the images of every container in the image group `group` to the image range `userRange`,
+where `max` is the largest image count in the group.
+Returns an error if `userRange` is invalid, in which case `group` is left unchanged.
+*/
+func selectImageRange(group imageGroup, userRange string, max int) error {
+	imgRange, err := seq.ParseSequenceString(userRange, max, false)
+	if err != nil {
+		return err
+	}
+
+	for i, c := range group.containers {
+		var imgNums []int
+		for _, n := range imgRange {
+			if n <= group.counts[i] {
+				imgNums = append(imgNums, n)
+			}
+		}
+		c.SelectImages(imgNums)
+	}
+
+	return nil
+}
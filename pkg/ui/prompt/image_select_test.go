@@ -0,0 +1,154 @@
+package prompt
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+/*
+Returns an anime title with an episode per image count of `counts`, selected by the episode ranges `episodeRanges`,
+where the i-th episode was selected by the episode range at index `rangeIndexes[i]`.
+*/
+func newTestTitle(counts []int, episodeRanges []string, rangeIndexes []int) *types.Title {
+	title := &types.Title{Category: types.CategoryAnime, Name: "Anime", Images: &types.Images{}, EpisodeRanges: episodeRanges}
+	for i, count := range counts {
+		e := &types.Episode{Title: title, Name: fmt.Sprintf("Episode %d", i+1), Images: &types.Images{}, RangeIndex: rangeIndexes[i]}
+		for n := 1; n <= count; n++ {
+			e.Images.AddURL(fmt.Sprintf("%d/%d.jpg", i+1, n))
+			e.IncrementImageTotal()
+		}
+		title.Episodes = append(title.Episodes, e)
+	}
+
+	return title
+}
+
+func TestGetImageGroups(t *testing.T) {
+	movie := &types.Title{Category: types.CategoryMovie, Name: "Movie", Images: &types.Images{}}
+	for n := 1; n <= 3; n++ {
+		movie.Images.AddURL(fmt.Sprintf("%d.jpg", n))
+		movie.IncrementImageTotal()
+	}
+
+	tests := []struct {
+		name   string       // Name of the test.
+		title  *types.Title // Title to group.
+		labels []string     // Expected labels of the groups.
+		counts [][]int      // Expected image counts of the containers of each group.
+	}{
+		{"movie", movie, []string{""}, [][]int{{3}}},
+		{"no episode ranges", newTestTitle([]int{2, 3}, nil, []int{0, 0}), []string{""}, [][]int{{2, 3}}},
+		{"episode ranges", newTestTitle([]int{2, 3, 4}, []string{"1-2", "5"}, []int{0, 1, 0}), []string{"Episodes 1-2", "Episodes 5"}, [][]int{{2, 4}, {3}}},
+		{"empty episode range", newTestTitle([]int{2}, []string{"1", "9"}, []int{0}), []string{"Episodes 1", "Episodes 9"}, [][]int{{2}, nil}},
+		{"out of range index", newTestTitle([]int{2, 3}, []string{"1-2"}, []int{0, 4}), []string{"Episodes 1-2"}, [][]int{{2, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := getImageGroups(tt.title)
+
+			var labels []string
+			var counts [][]int
+			for _, g := range groups {
+				labels = append(labels, g.label)
+				counts = append(counts, g.counts)
+				if len(g.containers) != len(g.counts) {
+					t.Errorf("group %q has %d containers and %d counts; want as many", g.label, len(g.containers), len(g.counts))
+				}
+			}
+
+			if !reflect.DeepEqual(labels, tt.labels) || !reflect.DeepEqual(counts, tt.counts) {
+				t.Errorf("getImageGroups() = %q %v; want %q %v", labels, counts, tt.labels, tt.counts)
+			}
+		})
+	}
+}
+
+func TestSelectImageRange(t *testing.T) {
+	tests := []struct {
+		input     string  // Image range.
+		expected  [][]int // Expected frames of each episode. (With 3 and 5 images)
+		expectErr bool    // True if an error is expected from the given input.
+	}{
+		{"1-", [][]int{{1, 2, 3}, {1, 2, 3, 4, 5}}, false},
+		{"2-4", [][]int{{2, 3}, {2, 3, 4}}, false},
+		{"1-:2", [][]int{{1, 3}, {1, 3, 5}}, false},
+		{"5", [][]int{{}, {5}}, false}, // Beyond the image count of the first episode.
+		{"4-5", [][]int{{}, {4, 5}}, false},
+		{"6", [][]int{{}, {}}, false}, // Beyond the largest image count: Nothing is left.
+
+		{"5-6", nil, true}, // Ranges may not end beyond the largest image count.
+		{"0-2", nil, true},
+		{"", nil, true},
+		{"foo", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			title := newTestTitle([]int{3, 5}, nil, []int{0, 0})
+			group := getImageGroups(title)[0]
+
+			err := selectImageRange(group, tt.input, 5)
+
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("selectImageRange(%q) expected error but got nil", tt.input)
+				}
+				if title.Total() != 8 {
+					t.Errorf("Total() = %d after invalid range %q; want 8 (unchanged)", title.Total(), tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("selectImageRange(%q) returned unexpected error: %v", tt.input, err)
+				return
+			}
+
+			var frames [][]int
+			total := uint32(0)
+			for _, e := range title.Episodes {
+				frames = append(frames, e.Images.Frames())
+				total += e.Total()
+			}
+			if !reflect.DeepEqual(frames, tt.expected) {
+				t.Errorf("selectImageRange(%q) kept frames %v; want %v", tt.input, frames, tt.expected)
+			}
+			if title.Total() != total {
+				t.Errorf("title Total() = %d; want %d (sum of its episodes)", title.Total(), total)
+			}
+		})
+	}
+}
+
+func TestSelectImages(t *testing.T) {
+	tests := []struct {
+		name     string   // Name of the test.
+		input    []string // Image range arguments.
+		expected []uint32 // Expected totals of the episodes.
+	}{
+		{"none", nil, []uint32{3, 4, 5}},
+		{"single range for all", []string{"1-2"}, []uint32{2, 2, 2}},
+		{"per episode range", []string{"1", "2-"}, []uint32{1, 3, 1}},
+		{"more ranges", []string{"1", "1-2", "3"}, []uint32{1, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			/* Episodes 1 and 3 share the first episode range. */
+			title := newTestTitle([]int{3, 4, 5}, []string{"1,3", "2"}, []int{0, 1, 0})
+			SelectImages([]*types.Title{title}, tt.input, false, false)
+
+			var totals []uint32
+			for _, e := range title.Episodes {
+				totals = append(totals, e.Total())
+			}
+			if !reflect.DeepEqual(totals, tt.expected) {
+				t.Errorf("SelectImages(%q) left totals %v; want %v", tt.input, totals, tt.expected)
+			}
+		})
+	}
+}
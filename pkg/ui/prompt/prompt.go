@@ -3,29 +3,38 @@ package prompt
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 
 	"golang.org/x/term"
+	"sheeper.com/fancaps-scraper-go/pkg/ui"
 )
 
+/* Reader of standard input, shared by all prompts so that no buffered (piped) input is lost between them. */
+var stdin = bufio.NewReader(os.Stdin)
+
 /*
 Returns the text from the user prompt with prompt text `promptText`
 and renders a help description `helpText`.
+
+If standard input is not a terminal, the next line of input is the reply, which is echoed.
+Returns an empty string once input is exhausted.
 */
 func TextPrompt(promptText, helpText string) string {
-	fmt.Println(helpText)
+	interactive := ui.StdinIsTerminal()
+	if interactive {
+		fmt.Println(helpText)
+	}
 	fmt.Print(promptText)
 
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		return scanner.Text()
-	}
-	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+	line, _ := readLine()
+	if !interactive {
+		fmt.Println(line)
 	}
 
-	return ""
+	return line
 }
 
 /*
@@ -34,8 +43,14 @@ and returns false otherwise.
 
 Note that since this function sets the terminal to raw mode, all signals such as
 SIGINT and SIGTERM are disabled.
+If standard input is not a terminal, the next line of input is the reply instead.
 */
 func YesNoPrompt(promptText, helpText string) bool {
+	if !ui.StdinIsTerminal() {
+		reply := strings.TrimSpace(TextPrompt(promptText, helpText))
+		return strings.EqualFold(reply, "y") || strings.EqualFold(reply, "yes")
+	}
+
 	fmt.Println(helpText)
 	fmt.Print(promptText)
 
@@ -46,8 +61,7 @@ func YesNoPrompt(promptText, helpText string) bool {
 	}
 	defer term.Restore(int(os.Stdin.Fd()), oldState)
 
-	reader := bufio.NewReader(os.Stdin)
-	char, _, err := reader.ReadRune()
+	char, _, err := stdin.ReadRune()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -57,3 +71,52 @@ func YesNoPrompt(promptText, helpText string) bool {
 
 	return char == 'y' || char == 'Y'
 }
+
+/*
+Returns the lines of standard input up to the first empty line, or until input is exhausted.
+Leading and trailing whitespace of lines is removed.
+*/
+func ReadLines() []string {
+	var lines []string
+	for {
+		line, ok := readLine()
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+		if !ok || line == "" {
+			return lines
+		}
+	}
+}
+
+/*
+Returns the next line of standard input, without its line ending.
+Returns false, if input is exhausted, along with any text left before its end.
+*/
+func readLine() (string, bool) {
+	line, err := stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		log.Fatal(err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	return line, err == nil
+}
+
+/*
+Prints the error `err` about a reply, and asks the user to try again.
+If standard input is not a terminal, the next lines were not written for a retry,
+so this function exits with code 1 instead.
+*/
+func retryPrompt(err error) {
+	if !ui.StdinIsTerminal() {
+		fmt.Fprintln(os.Stderr, ui.ErrStyle.Render(err.Error()))
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr,
+		ui.ErrStyle.Render("%v")+"\n"+
+			ui.ErrStyle.Render("try again")+"\n\n",
+		err)
+}
@@ -0,0 +1,92 @@
+package prompt
+
+import (
+	"bufio"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+/*
+Replaces standard input with the piped input `input` for the duration of the test `t`,
+so that prompts read it line by line, as without a terminal.
+*/
+func setStdin(t *testing.T, input string) {
+	t.Helper()
+
+	devNull, err := os.Open(os.DevNull) // Not a terminal.
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldFile, oldReader := os.Stdin, stdin
+	os.Stdin, stdin = devNull, bufio.NewReader(strings.NewReader(input))
+	t.Cleanup(func() {
+		os.Stdin, stdin = oldFile, oldReader
+		devNull.Close()
+	})
+}
+
+func TestReadLines(t *testing.T) {
+	tests := []struct {
+		name     string   // Name of the test.
+		input    string   // Piped input.
+		expected []string // Expected lines.
+		rest     string   // Expected next line of input, after the lines.
+	}{
+		{"empty", "", nil, ""},
+		{"up to empty line", "Naruto\nBleach\n\nOne Piece\n", []string{"Naruto", "Bleach"}, "One Piece"},
+		{"until exhausted", "Naruto\nBleach", []string{"Naruto", "Bleach"}, ""},
+		{"trimmed", "  Naruto \r\n\tBleach\r\n\r\n", []string{"Naruto", "Bleach"}, ""},
+		{"whitespace line ends", "Naruto\n   \nBleach\n", []string{"Naruto"}, "Bleach"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setStdin(t, tt.input)
+
+			if lines := ReadLines(); !slices.Equal(lines, tt.expected) {
+				t.Errorf("ReadLines() = %q; want %q", lines, tt.expected)
+			}
+			if rest, _ := readLine(); rest != tt.rest {
+				t.Errorf("next line = %q; want %q", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	setStdin(t, "first\r\nsecond\nlast")
+
+	want := []struct {
+		line string // Expected line.
+		ok   bool   // True if input is expected to continue.
+	}{
+		{"first", true},
+		{"second", true},
+		{"last", false},
+		{"", false},
+	}
+	for _, w := range want {
+		if line, ok := readLine(); line != w.line || ok != w.ok {
+			t.Errorf("readLine() = %q, %t; want %q, %t", line, ok, w.line, w.ok)
+		}
+	}
+}
+
+func TestPromptsWithoutTerminal(t *testing.T) {
+	setStdin(t, " Naruto \ny\nno\n")
+
+	if got := TextPrompt("Query: ", "help"); got != " Naruto " {
+		t.Errorf("TextPrompt() = %q; want %q", got, " Naruto ")
+	}
+	if !YesNoPrompt("Continue? ", "help") {
+		t.Error("YesNoPrompt() = false after \"y\"; want true")
+	}
+	if YesNoPrompt("Continue? ", "help") {
+		t.Error("YesNoPrompt() = true after \"no\"; want false")
+	}
+	if YesNoPrompt("Continue? ", "help") {
+		t.Error("YesNoPrompt() = true once input is exhausted; want false")
+	}
+}
@@ -0,0 +1,72 @@
+package prompt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sheeper.com/fancaps-scraper-go/pkg/seq"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+	"sheeper.com/fancaps-scraper-go/pkg/ui"
+)
+
+/* Returns the rendered text for the selection of titles among `count` titles. */
+func selectTitleHelp(count int) string {
+	max := strconv.Itoa(count)
+
+	return strings.Join([]string{
+		ui.HelpStyle.Render("Provide the numbers of the titles you'd like to scrape."),
+		ui.HelpStyle.Render("(e.g., 1, 1-3, 2,4, " + "-" + max + ",  etc.)"),
+		ui.HelpStyle.Render("Default: All. (1-" + max + ") [Leave empty for default]"),
+	}, "\n")
+}
+
+/*
+Returns the titles selected from titles `titles` by number, as a line-based alternative to the title menu
+for when there is no terminal to show it in. (See `menu.LaunchTitleMenu()`)
+
+The titles are listed with their numbers, and the user is prompted for a range of title numbers.
+If standard input is not a terminal, an invalid range makes this function print an error and exit with code 1.
+If `debug` is enabled, print the selected titles.
+*/
+func SelectTitles(titles []*types.Title, debug bool) []*types.Title {
+	for i, title := range titles {
+		fmt.Printf("%3d. %s [%s]\n", i+1, title.Name, title.Category)
+	}
+
+	var selected []*types.Title
+	for {
+		userRange := TextPrompt("Enter Title Numbers: ", selectTitleHelp(len(titles)))
+		if strings.TrimSpace(userRange) == "" { // Default to all titles if user doesn't specify a range.
+			userRange = "1-" + strconv.Itoa(len(titles))
+		}
+
+		nums, err := seq.ParseSequenceString(userRange, len(titles), false)
+		switch {
+		case err != nil:
+		case len(nums) == 0:
+			err = fmt.Errorf("no titles selected by `%s`", userRange)
+		case nums[0] < 1 || nums[len(nums)-1] > len(titles):
+			err = fmt.Errorf("title numbers must be between 1 and %d", len(titles))
+		}
+		if err != nil {
+			retryPrompt(err)
+			continue
+		}
+
+		for _, n := range nums {
+			selected = append(selected, titles[n-1])
+		}
+		break
+	}
+
+	if debug {
+		fmt.Println("\nSELECTED TITLES:")
+		for _, title := range selected {
+			fmt.Printf("%s [%s] -> %s\n", title.Name, title.Category, title.Url)
+		}
+	}
+	fmt.Println()
+
+	return selected
+}
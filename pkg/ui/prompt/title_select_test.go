@@ -0,0 +1,48 @@
+package prompt
+
+import (
+	"slices"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+func TestSelectTitles(t *testing.T) {
+	naruto := &types.Title{Category: types.CategoryAnime, Name: "Naruto"}
+	office := &types.Title{Category: types.CategoryTV, Name: "The Office"}
+	akira := &types.Title{Category: types.CategoryMovie, Name: "Akira"}
+	titles := []*types.Title{naruto, office, akira}
+
+	tests := []struct {
+		input    string         // Piped input.
+		expected []*types.Title // Expected selected titles.
+	}{
+		{"\n", titles}, // Default: All.
+		{"", titles},   // Input exhausted.
+		{"2\n", []*types.Title{office}},
+		{"1,3\n", []*types.Title{naruto, akira}},
+		{" 2- \n", []*types.Title{office, akira}},
+		{"-2\n", []*types.Title{naruto, office}},
+		{"1-3:2\n", []*types.Title{naruto, akira}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			setStdin(t, tt.input)
+
+			if got := SelectTitles(titles, false); !slices.Equal(got, tt.expected) {
+				t.Errorf("SelectTitles(%q) = %s; want %s", tt.input, titleNames(got), titleNames(tt.expected))
+			}
+		})
+	}
+}
+
+/* Returns the names of titles `titles`. */
+func titleNames(titles []*types.Title) []string {
+	var names []string
+	for _, t := range titles {
+		names = append(names, t.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,17 @@
+package ui
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+/* Returns true, if standard input is a terminal. Otherwise, input is piped or redirected, and read line by line. */
+func StdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+/* Returns true, if standard output is a terminal. Otherwise, output is piped or redirected, and cannot be redrawn. */
+func StdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
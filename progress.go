@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"sheeper.com/fancaps-scraper-go/pkg/cli"
+	"sheeper.com/fancaps-scraper-go/pkg/progress"
+	"sheeper.com/fancaps-scraper-go/pkg/scraper"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+	"sheeper.com/fancaps-scraper-go/pkg/ui"
+	"sheeper.com/fancaps-scraper-go/pkg/ui/progressbar"
+)
+
+/*
+Reserves standard output for the JSON progress of flags `flags`, unless it is written to the progress file,
+so that standard output stays machine-parseable. Any other output then goes to standard error.
+Returns the original standard output, to write plain or JSON progress to.
+*/
+func reserveStdout(flags cli.CLIFlags) io.Writer {
+	stdout := os.Stdout
+	if flags.Progress == progress.ModeJSON && flags.ProgressFile == "" && !flags.DryRun {
+		os.Stdout = os.Stderr
+	}
+
+	return stdout
+}
+
+/*
+Returns the observer showing the download progress of titles `titles` in the progress mode of flags `flags`,
+along with a function to call once downloads are done, which closes the progress file (if any).
+Progress bars show the request rate of the client `client`, and fall back to plain lines
+if standard output is not a terminal. Plain and JSON progress go to `out`, unless written to the progress file.
+(See `reserveStdout()`)
+
+If the progress file cannot be created, this function prints an error and exits with code 1.
+*/
+func newProgressObserver(flags cli.CLIFlags, client *scraper.Client, titles []*types.Title, out io.Writer) (progress.Observer, func()) {
+	/* Progress bars are redrawn in place, which only works in terminals. */
+	mode := flags.Progress
+	if mode == progress.ModeBar && !ui.StdoutIsTerminal() {
+		mode = progress.ModePlain
+	}
+
+	if mode == progress.ModeBar {
+		fmt.Println(":: Showing progress...")
+		progressbar.SetRateSource(client.Rate)
+		return progressbar.NewObserver(titles), func() {}
+	}
+
+	w := out
+	closeFile := func() error { return nil }
+	if flags.ProgressFile != "" {
+		f, err := os.Create(flags.ProgressFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("progress error: %v")+"\n", err)
+			os.Exit(exitError)
+		}
+		w, closeFile = f, f.Close
+	}
+
+	if mode == progress.ModePlain {
+		return progress.NewPlainPrinter(w), func() { reportProgressError(closeFile()) }
+	}
+
+	emitter := progress.NewJSONEmitter(w, titles)
+	return emitter, func() {
+		reportProgressError(emitter.Err())
+		reportProgressError(closeFile())
+	}
+}
+
+/* Prints the error `err` encountered while writing progress, if any. Downloads are unaffected by it. */
+func reportProgressError(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("failed to write progress: %v")+"\n", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/cli"
+	"sheeper.com/fancaps-scraper-go/pkg/progress"
+)
+
+func TestReserveStdout(t *testing.T) {
+	tests := []struct {
+		name     string       // Name of the test.
+		flags    cli.CLIFlags // Parsed flags.
+		reserved bool         // If true, other output is expected to go to stderr.
+	}{
+		{"json", cli.CLIFlags{Progress: progress.ModeJSON}, true},
+		{"json to file", cli.CLIFlags{Progress: progress.ModeJSON, ProgressFile: "progress.jsonl"}, false},
+		{"json dry run", cli.CLIFlags{Progress: progress.ModeJSON, DryRun: true}, false},
+		{"plain", cli.CLIFlags{Progress: progress.ModePlain}, false},
+		{"bar", cli.CLIFlags{Progress: progress.ModeBar}, false},
+	}
+
+	stdout := os.Stdout
+	defer func() { os.Stdout = stdout }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Stdout = stdout
+
+			if out := reserveStdout(tt.flags); out != stdout {
+				t.Errorf("reserveStdout() = %v, expected the original stdout", out)
+			}
+			if reserved := os.Stdout == os.Stderr; reserved != tt.reserved {
+				t.Errorf("other output on stderr = %t, expected %t", reserved, tt.reserved)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sheeper.com/fancaps-scraper-go/pkg/cli"
+	"sheeper.com/fancaps-scraper-go/pkg/job"
+	"sheeper.com/fancaps-scraper-go/pkg/report"
+	"sheeper.com/fancaps-scraper-go/pkg/scraper"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+	"sheeper.com/fancaps-scraper-go/pkg/ui"
+)
+
+/*
+Makes every exit through `exit()` write the report of the run recorded by `recorder`, (See `writeReport()`)
+covering the titles `*titles` and job manifest `*manifest` as they are when exiting.
+Early exits (e.g., after a failed scrape, or an interrupt) leave a report of the run so far.
+*/
+func writeReportOnExit(flags cli.CLIFlags, client *scraper.Client, recorder *report.Recorder, titles *[]*types.Title, manifest **job.Manifest) {
+	beforeExit = func() {
+		writeReport(flags, client, recorder, *titles, *manifest)
+	}
+}
+
+/*
+Writes the report of the run recorded by `recorder` to the report file of flags `flags`, covering the titles `titles`
+downloaded by the client `client` along with the job manifest `manifest`. (nil, if the downloads created their own)
+Prints an error, if the report cannot be written. The run is unaffected by it.
+*/
+func writeReport(flags cli.CLIFlags, client *scraper.Client, recorder *report.Recorder, titles []*types.Title, manifest *job.Manifest) {
+	rep := recorder.Report(titles)
+	rep.Flags = flags.Used
+
+	/* Downloads without a manifest create one in the output directory. (See `scraper.Download()`) */
+	manifestPath := filepath.Join(flags.OutputDir, job.ManifestName)
+	if manifest != nil {
+		manifestPath = manifest.Path()
+		rep.ManifestCreated = manifest.Created
+		rep.FrameNames = manifest.FrameNames
+	}
+	if abs, err := filepath.Abs(manifestPath); err == nil {
+		manifestPath = abs
+	}
+	rep.Manifest = manifestPath
+
+	for _, f := range client.FailedPages() {
+		rep.FailedPages = append(rep.FailedPages, report.FailedPage{Url: f.URL, Error: f.Err.Error()})
+	}
+
+	if err := rep.Write(flags.Report); err != nil {
+		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("report error: %v")+"\n", err)
+	}
+}
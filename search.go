@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"sheeper.com/fancaps-scraper-go/pkg/job"
+	"sheeper.com/fancaps-scraper-go/pkg/report"
+	"sheeper.com/fancaps-scraper-go/pkg/scraper"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+	"sheeper.com/fancaps-scraper-go/pkg/ui"
+	"sheeper.com/fancaps-scraper-go/pkg/ui/prompt"
+)
+
+/* Rendered help text for a search query prompt. */
+var queryHelpPrompt = strings.Join([]string{
+	ui.HelpStyle.Render("Type the name of a movie, TV series, or anime you'd like to search for."),
+	ui.HelpStyle.Render(`(e.g., "Predator", "Family Guy", "Hunter x Hunter", etc.)`),
+	ui.HelpStyle.Render("Tip: You can enter just part of a title to search."),
+}, "\n")
+
+/*
+Returns the unique, sorted titles found by the client `client` for queries `queries`,
+searching only categories in `categories`.
+
+If no queries have been specified, this function will prompt the user for queries and
+search them incrementally, and searches all queries in parallel otherwise.
+If standard input is not a terminal, queries are read from its lines instead, up to the first empty line.
+If a query is empty or has no titles, this function prints an error and exits with its exit code. (See `exitCode()`)
+*/
+func searchTitles(ctx context.Context, client *scraper.Client, queries []string, categories []types.Category) []*types.Title {
+	var found [][]*types.Title // Titles found for each query.
+
+	if len(queries) == 0 && !ui.StdinIsTerminal() {
+		queries = prompt.ReadLines()
+		if len(queries) == 0 {
+			exitOnError(ctx, fmt.Errorf("no search queries read from standard input: %w", scraper.ErrEmptyQuery))
+		}
+	}
+
+	if len(queries) == 0 { // Prompt and search queries incrementally.
+		for len(found) == 0 || prompt.YesNoPrompt("Enter another query? [y/N]: ", "") {
+			query := prompt.TextPrompt("Enter Search Query: ", queryHelpPrompt)
+
+			titles, err := client.Search(ctx, query, categories)
+			switch {
+			case errors.Is(err, scraper.ErrEmptyQuery):
+				fmt.Fprintln(os.Stderr, ui.ErrStyle.Render("search query cannot be empty.")+"\n")
+				continue
+			case errors.Is(err, scraper.ErrNotFound):
+				fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("no titles found for query `%s`.")+"\n", query)
+				continue
+			case err != nil:
+				exitOnError(ctx, err)
+			}
+			fmt.Printf(ui.SuccessStyle.Render("Found titles for query: `%s`")+"\n", query)
+			found = append(found, titles)
+		}
+	} else { // Search queries all at once.
+		found = make([][]*types.Title, len(queries))
+
+		eg, egCtx := errgroup.WithContext(ctx)
+		for i, query := range queries {
+			eg.Go(func() error {
+				titles, err := client.Search(egCtx, query, categories)
+				found[i] = titles
+				return err
+			})
+		}
+		if err := eg.Wait(); err != nil {
+			exitOnError(ctx, err)
+		}
+	}
+
+	return scraper.MergeTitles(found...)
+}
+
+/*
+Asks the user whether to replace the unfinished job in the output directory `outputDir` (if any) with a new job.
+Without a terminal, or if the user declines, this function prints how to resume the job and exits with code 1.
+*/
+func confirmNewJob(outputDir string) {
+	n := job.Unfinished(outputDir)
+	if n == 0 {
+		return
+	}
+
+	manifestPath := filepath.Join(outputDir, job.ManifestName)
+	help := strings.Join([]string{
+		ui.HelpStyle.Render(fmt.Sprintf("%s holds an unfinished job with %d pending image(s).", manifestPath, n)),
+		ui.HelpStyle.Render("A new job replaces it, so it can no longer be resumed."),
+	}, "\n")
+	if ui.StdinIsTerminal() && prompt.YesNoPrompt("Replace the unfinished job? [y/N] ", help) {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("%s holds an unfinished job with %d pending image(s).")+"\n", manifestPath, n)
+	fmt.Fprintf(os.Stderr, "Resume it with --resume %s, or use another --output-dir.\n", manifestPath)
+	os.Exit(exitError)
+}
+
+/*
+Returns the job manifest read from the file `filename`, along with its titles,
+keeping only images which are still pending or failed.
+
+If the manifest cannot be read, this function prints an error and exits with code 1.
+If no images are left to download, this function says so and exits with code 0.
+*/
+func loadManifest(filename string) (*job.Manifest, []*types.Title) {
+	manifest, err := job.Load(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("resume error: %v")+"\n", err)
+		os.Exit(exitError)
+	}
+
+	titles, err := manifest.PendingTitles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("resume error: %s: %v")+"\n", filename, err)
+		os.Exit(exitError)
+	}
+	if len(titles) == 0 {
+		fmt.Printf("Nothing to resume: all images of %s are done.\n", filename)
+		os.Exit(exitOK)
+	}
+
+	return manifest, titles
+}
+
+/*
+Returns the titles of the images which failed to download in a previous run, along with the job manifest recording their retry.
+`filename` is either the report of the run (See `report.Report`) or its job manifest.
+
+The failed images of a report are retried in the output directory of its run. Its job manifest records the retry,
+unless a later run replaced it, in which case the retry is a new job. (See `confirmNewJob()`)
+
+If the report or manifest cannot be read, this function prints an error and exits with code 1.
+If no images failed, this function says so and exits with code 0.
+*/
+func loadFailedImages(filename string) (*job.Manifest, []*types.Title) {
+	rep, err := report.Load(filename)
+	if err != nil { // Not a report, but a job manifest.
+		return loadFailedManifest(filename)
+	}
+
+	titles, err := rep.FailedTitles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("retry error: %s: %v")+"\n", filename, err)
+		os.Exit(exitError)
+	}
+	if len(titles) == 0 {
+		fmt.Printf("Nothing to retry: no image of %s failed.\n", filename)
+		os.Exit(exitOK)
+	}
+
+	if manifest, ok := rep.LoadManifest(); ok {
+		return manifest, titles
+	}
+	if rep.Manifest == "" {
+		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("retry error: %s does not name the job manifest of its run")+"\n", filename)
+		os.Exit(exitError)
+	}
+
+	fmt.Printf("%s no longer records the run of %s. Retrying its failed images as a new job...\n", rep.Manifest, filename)
+	outputDir := filepath.Dir(rep.Manifest)
+	confirmNewJob(outputDir)
+	manifest := job.New(outputDir, titles)
+	manifest.FrameNames = rep.FrameNames
+
+	return manifest, titles
+}
+
+/*
+Returns the job manifest read from the file `filename`, along with its titles,
+keeping only images which failed to download.
+
+If the manifest cannot be read, this function prints an error and exits with code 1.
+If no images failed, this function says so and exits with code 0.
+*/
+func loadFailedManifest(filename string) (*job.Manifest, []*types.Title) {
+	manifest, err := job.Load(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("retry error: %v")+"\n", err)
+		os.Exit(exitError)
+	}
+
+	titles, err := manifest.FailedTitles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ui.ErrStyle.Render("retry error: %s: %v")+"\n", filename, err)
+		os.Exit(exitError)
+	}
+	if len(titles) == 0 {
+		fmt.Printf("Nothing to retry: no image of %s failed.\n", filename)
+		os.Exit(exitOK)
+	}
+
+	return manifest, titles
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"sheeper.com/fancaps-scraper-go/pkg/logf"
+	"sheeper.com/fancaps-scraper-go/pkg/scraper"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+	"sheeper.com/fancaps-scraper-go/pkg/ui"
+)
+
+/*
+Prints the pages which could not be fetched or scraped by the client `client` (if any),
+the images of titles `titles` which failed to download (if any), along with log statistics.
+Exits with the exit code of the run. (See `summaryExitCode()`)
+*/
+func exitWithSummary(client *scraper.Client, titles []*types.Title) {
+	printFailedImages(titles)
+
+	failed := client.FailedPages()
+	if len(failed) > 0 {
+		fmt.Fprintln(os.Stderr, "\n\n"+ui.ErrStyle.Render(fmt.Sprintf("%d page(s) could not be scraped. Their titles, episodes or images are missing:", len(failed))))
+		for _, f := range failed {
+			fmt.Fprintf(os.Stderr, "\t%s\n\t\t%v\n", f.URL, f.Err)
+		}
+	}
+
+	logf.PrintStats()
+	exit(summaryExitCode(failed, titles))
+}
+
+/*
+Returns the exit code of a run with the failed pages `failedPages`, downloading the titles `titles`:
+the exit code of the first failed page, if any, (See `exitCode()`) or code 7, if any image failed to download.
+Otherwise, returns code 0.
+*/
+func summaryExitCode(failedPages []scraper.PageFailure, titles []*types.Title) int {
+	if len(failedPages) > 0 {
+		return exitCode(failedPages[0].Err)
+	}
+	if types.SumCounts(titles).Failed > 0 {
+		return exitImagesFailed
+	}
+
+	return exitOK
+}
+
+/* Prints the images of titles `titles` which failed to download, grouped by title (Movies) or episode. */
+func printFailedImages(titles []*types.Title) {
+	failed := uint32(0)
+	for _, t := range titles {
+		failed += t.Failed()
+	}
+	if failed == 0 {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "\n\n"+ui.ErrStyle.Render(fmt.Sprintf("%d image(s) failed to download:", failed)))
+
+	/* Prints the failed images of the title or episode `imgCon` named `name`, holding the images `images`. */
+	printContainer := func(imgCon types.ImageContainer, name string, images *types.Images) {
+		if imgCon.Failed() == 0 {
+			return
+		}
+
+		fmt.Fprintf(os.Stderr, "\t%s (%d of %d failed)\n", name, imgCon.Failed(), imgCon.Total())
+		for _, img := range images.List() {
+			if img.Status == types.ImageFailed {
+				fmt.Fprintf(os.Stderr, "\t\t%s\n\t\t\t%s\n", img.Url, img.Error)
+			}
+		}
+	}
+
+	for _, t := range titles {
+		if t.Category == types.CategoryMovie {
+			printContainer(t, t.Name, t.Images)
+			continue
+		}
+		for _, e := range t.Episodes {
+			printContainer(e, t.Name+" - "+e.Name, e.Images)
+		}
+	}
+}
+
+/* Returns the titles of `titles` which have episodes, along with movies. (which have none) */
+func withEpisodes(titles []*types.Title) []*types.Title {
+	var kept []*types.Title
+	for _, t := range titles {
+		if t.Category == types.CategoryMovie || len(t.Episodes) > 0 {
+			kept = append(kept, t)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"sheeper.com/fancaps-scraper-go/pkg/scraper"
+	"sheeper.com/fancaps-scraper-go/pkg/types"
+)
+
+func TestSummaryExitCode(t *testing.T) {
+	failedTitle := &types.Title{Name: "Failed", Images: &types.Images{}}
+	failedTitle.IncrementImageTotal()
+	failedTitle.IncrementFailed()
+
+	doneTitle := &types.Title{Name: "Done", Images: &types.Images{}}
+	doneTitle.IncrementImageTotal()
+	doneTitle.IncrementDownloaded()
+
+	tests := []struct {
+		name        string                // Name of the test.
+		failedPages []scraper.PageFailure // Pages which could not be scraped.
+		titles      []*types.Title        // Titles of the run.
+		expected    int                   // Expected exit code.
+	}{
+		{"no failures", nil, []*types.Title{doneTitle}, exitOK},
+		{"failed images", nil, []*types.Title{doneTitle, failedTitle}, exitImagesFailed},
+		{"failed page", []scraper.PageFailure{{URL: "https://fancaps.net", Err: scraper.ErrRateLimited}}, []*types.Title{doneTitle}, exitRateLimited},
+		{"failed page takes precedence", []scraper.PageFailure{{URL: "https://fancaps.net", Err: errors.New("fail")}}, []*types.Title{failedTitle}, exitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := summaryExitCode(tt.failedPages, tt.titles); code != tt.expected {
+				t.Errorf("summaryExitCode() = %d, expected %d", code, tt.expected)
+			}
+		})
+	}
+}